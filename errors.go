@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+)
+
+// An InputError is a failure to read the input or the request, for example
+// an invalid input or an observed composite resource that cannot be
+// converted. An InputError about the whole input stops the function before any
+// hook is evaluated. One about a single hook, such as failing to determine
+// whether it is enabled, only skips that hook.
+type InputError struct {
+	Err error
+}
+
+func (e *InputError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// A MatchError is a failure to evaluate a matcher of a status condition hook.
+type MatchError struct {
+	// Op describes what failed, e.g. "cannot match resources".
+	Op string
+	// HookIndex is the index of the hook in statusConditionHooks.
+	HookIndex int
+	// MatcherIndex is the index of the matcher in the hook's matchers.
+	MatcherIndex int
+	Err          error
+}
+
+func (e *MatchError) Error() string {
+	return fmt.Sprintf("%s, statusConditionHookIndex: %d, matchConditionIndex: %d: %s", e.Op, e.HookIndex, e.MatcherIndex, e.Err)
+}
+
+func (e *MatchError) Unwrap() error {
+	return e.Err
+}
+
+// A HookLocation identifies a hook that sets conditions and creates events.
+type HookLocation struct {
+	// Index of the hook.
+	Index int
+	// AfterAllHooks is true if the hook is listed in whenAllHooksEvaluated
+	// rather than statusConditionHooks.
+	AfterAllHooks bool
+}
+
+func (l HookLocation) String() string {
+	if l.AfterAllHooks {
+		return fmt.Sprintf("whenAllHooksEvaluatedIndex: %d", l.Index)
+	}
+	return fmt.Sprintf("statusConditionHookIndex: %d", l.Index)
+}
+
+// A SetConditionError is a failure to set a condition of a hook.
+type SetConditionError struct {
+	// Op describes what failed, e.g. "cannot set condition".
+	Op string
+	// Hook that the condition belongs to.
+	Hook HookLocation
+	// SetConditionIndex is the index of the condition in the hook's
+	// setConditions.
+	SetConditionIndex int
+	Err               error
+}
+
+func (e *SetConditionError) Error() string {
+	return fmt.Sprintf("%s, %s, setConditionIndex: %d: %s", e.Op, e.Hook, e.SetConditionIndex, e.Err)
+}
+
+func (e *SetConditionError) Unwrap() error {
+	return e.Err
+}
+
+// A CreateEventError is a failure to create an event of a hook.
+type CreateEventError struct {
+	// Hook that the event belongs to.
+	Hook HookLocation
+	// CreateEventIndex is the index of the event in the hook's createEvents.
+	CreateEventIndex int
+	Err              error
+}
+
+func (e *CreateEventError) Error() string {
+	return fmt.Sprintf("cannot create event, %s, createEventIndex: %d: %s", e.Hook, e.CreateEventIndex, e.Err)
+}
+
+func (e *CreateEventError) Unwrap() error {
+	return e.Err
+}
+
+// A RollupError is a failure to roll up the readiness of the observed
+// resources.
+type RollupError struct {
+	Err error
+}
+
+func (e *RollupError) Error() string {
+	return fmt.Sprintf("cannot roll up readiness: %s", e.Err)
+}
+
+func (e *RollupError) Unwrap() error {
+	return e.Err
+}
+
+// A SuccessEventError is a failure to render the message of the success event.
+type SuccessEventError struct {
+	Err error
+}
+
+func (e *SuccessEventError) Error() string {
+	return fmt.Sprintf("cannot render success event message: %s", e.Err)
+}
+
+func (e *SuccessEventError) Unwrap() error {
+	return e.Err
+}
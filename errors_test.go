@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+func TestErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type want struct {
+		msg string
+		as  bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		err    error
+		as     func(err error) bool
+		want   want
+	}{
+		"InputError": {
+			reason: "An InputError should render the error it wraps.",
+			err:    &InputError{Err: errBoom},
+			as: func(err error) bool {
+				var e *InputError
+				return errors.As(err, &e)
+			},
+			want: want{msg: "boom", as: true},
+		},
+		"MatchError": {
+			reason: "A MatchError should render the indices of the hook and matcher.",
+			err:    &MatchError{Op: "cannot match resources", HookIndex: 1, MatcherIndex: 2, Err: errBoom},
+			as: func(err error) bool {
+				var e *MatchError
+				return errors.As(err, &e) && e.HookIndex == 1 && e.MatcherIndex == 2
+			},
+			want: want{msg: "cannot match resources, statusConditionHookIndex: 1, matchConditionIndex: 2: boom", as: true},
+		},
+		"SetConditionError": {
+			reason: "A SetConditionError should render the index of the hook and condition.",
+			err:    &SetConditionError{Op: "cannot set condition", Hook: HookLocation{Index: 1}, SetConditionIndex: 3, Err: errBoom},
+			as: func(err error) bool {
+				var e *SetConditionError
+				return errors.As(err, &e) && e.Hook.Index == 1 && e.SetConditionIndex == 3
+			},
+			want: want{msg: "cannot set condition, statusConditionHookIndex: 1, setConditionIndex: 3: boom", as: true},
+		},
+		"SetConditionErrorAfterAllHooks": {
+			reason: "A SetConditionError of a whenAllHooksEvaluated hook should say so.",
+			err:    &SetConditionError{Op: "cannot set condition", Hook: HookLocation{Index: 0, AfterAllHooks: true}, SetConditionIndex: 1, Err: errBoom},
+			as: func(err error) bool {
+				var e *SetConditionError
+				return errors.As(err, &e) && e.Hook.AfterAllHooks
+			},
+			want: want{msg: "cannot set condition, whenAllHooksEvaluatedIndex: 0, setConditionIndex: 1: boom", as: true},
+		},
+		"CreateEventError": {
+			reason: "A CreateEventError should render the index of the hook and event.",
+			err:    &CreateEventError{Hook: HookLocation{Index: 2}, CreateEventIndex: 0, Err: errBoom},
+			as: func(err error) bool {
+				var e *CreateEventError
+				return errors.As(err, &e) && e.Hook.Index == 2 && e.CreateEventIndex == 0
+			},
+			want: want{msg: "cannot create event, statusConditionHookIndex: 2, createEventIndex: 0: boom", as: true},
+		},
+		"RollupError": {
+			reason: "A RollupError should say that readiness could not be rolled up.",
+			err:    &RollupError{Err: errBoom},
+			as: func(err error) bool {
+				var e *RollupError
+				return errors.As(err, &e)
+			},
+			want: want{msg: "cannot roll up readiness: boom", as: true},
+		},
+		"SuccessEventError": {
+			reason: "A SuccessEventError should say that the success event message could not be rendered.",
+			err:    &SuccessEventError{Err: errBoom},
+			as: func(err error) bool {
+				var e *SuccessEventError
+				return errors.As(err, &e)
+			},
+			want: want{msg: "cannot render success event message: boom", as: true},
+		},
+		"DifferentType": {
+			reason: "A MatchError should not be mistaken for a SetConditionError.",
+			err:    &MatchError{Op: "cannot match resources", Err: errBoom},
+			as: func(err error) bool {
+				var e *SetConditionError
+				return errors.As(err, &e)
+			},
+			want: want{msg: "cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: boom", as: false},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want.msg, tc.err.Error()); diff != "" {
+				t.Errorf("%s\nError(): -want, +got:\n%s", tc.reason, diff)
+			}
+			wrapped := errors.Wrap(tc.err, "wrapped")
+			if diff := cmp.Diff(tc.want.as, tc.as(wrapped)); diff != "" {
+				t.Errorf("%s\nerrors.As(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if !errors.Is(wrapped, errBoom) {
+				t.Errorf("%s\nerrors.Is(...): want the wrapped error to be found", tc.reason)
+			}
+		})
+	}
+}
+
+func TestRunFunctionErrors(t *testing.T) {
+	input := func(fields string) string {
+		return `{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  ` + fields + `
+}`
+	}
+
+	cases := map[string]struct {
+		reason string
+		input  string
+		as     func(err error) bool
+	}{
+		"InputError": {
+			reason: "A failure to determine whether a hook is enabled should be an InputError.",
+			input: input(`"statusConditionHooks": [
+    {
+      "enabled": "maybe",
+      "matchers": [{"compositeOnly": true, "conditions": [{"type": "Synced", "exists": false}]}]
+    }
+  ]`),
+			as: func(err error) bool {
+				var e *InputError
+				return errors.As(err, &e)
+			},
+		},
+		"MatchError": {
+			reason: "A failure to match resources should be a MatchError.",
+			input: input(`"statusConditionHooks": [
+    {
+      "matchers": [{"resources": [{"name": "(?!"}], "conditions": [{"type": "Synced", "status": "True"}]}]
+    }
+  ]`),
+			as: func(err error) bool {
+				var e *MatchError
+				return errors.As(err, &e) && e.HookIndex == 0 && e.MatcherIndex == 0
+			},
+		},
+		"SetConditionError": {
+			reason: "A failure to render a condition message should be a SetConditionError.",
+			input: input(`"statusConditionHooks": [
+    {
+      "matchers": [{"compositeOnly": true, "conditions": [{"type": "Synced", "exists": false}]}],
+      "setConditions": [{"target": "Composite", "condition": {"type": "CustomReady", "status": "False", "reason": "Failed", "message": "{{ .Missing "}}]
+    }
+  ]`),
+			as: func(err error) bool {
+				var e *SetConditionError
+				return errors.As(err, &e) && e.SetConditionIndex == 0
+			},
+		},
+		"RollupError": {
+			reason: "A failure to roll up readiness should be a RollupError.",
+			input:  input(`"readinessRollup": {"resources": [{"name": "(?!"}]}`),
+			as: func(err error) bool {
+				var e *RollupError
+				return errors.As(err, &e)
+			},
+		},
+		"SuccessEventError": {
+			reason: "A failure to render the success event message should be a SuccessEventError.",
+			input: input(`"emitSuccessEvent": true,
+  "successEventMessage": "{{ .XRName "`),
+			as: func(err error) bool {
+				var e *SuccessEventError
+				return errors.As(err, &e)
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := &capturingLogger{entries: &[]logEntry{}}
+			f := &Function{log: log}
+			_, err := f.RunFunction(context.Background(), &fnv1.RunFunctionRequest{
+				Input: resource.MustStructJSON(tc.input),
+				Observed: &fnv1.State{
+					Composite: &fnv1.Resource{
+						Resource: resource.MustStructJSON(`{"apiVersion": "example.org/v1", "kind": "XR", "metadata": {"name": "example-xr"}}`),
+					},
+					Resources: map[string]*fnv1.Resource{
+						"example-mr": {
+							Resource: resource.MustStructJSON(`{"apiVersion": "some.example.com/v1alpha1", "kind": "Object", "status": {"conditions": [{"type": "Synced", "status": "True"}]}}`),
+						},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("%s\nf.RunFunction(...): unexpected error: %v", tc.reason, err)
+			}
+
+			var errs []error
+			for _, e := range *log.entries {
+				if e.msg != "recording failure" {
+					continue
+				}
+				if err, ok := e.fields["error"].(error); ok {
+					errs = append(errs, err)
+				}
+			}
+			if len(errs) != 1 {
+				t.Fatalf("%s\nf.RunFunction(...): want one recorded failure, got %v", tc.reason, errs)
+			}
+			if !tc.as(errs[0]) {
+				t.Errorf("%s\nf.RunFunction(...): recorded failure %q (%T) is not of the expected type", tc.reason, errs[0], errs[0])
+			}
+		})
+	}
+}
@@ -3,15 +3,35 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
+	"net/http"
 	"regexp"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
 
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
@@ -30,49 +50,192 @@ const (
 
 	// Condition reasons.
 	reasonAvailable                = "Available"
+	reasonNoMatch                  = "NoMatch"
+	reasonNoHooks                  = "NoHooks"
+	reasonUnavailable              = "Unavailable"
 	reasonInputFailure             = "InputFailure"
 	reasonObservedCompositeFailure = "ObservedCompositeFailure"
 	reasonMatchFailure             = "MatchFailure"
 	reasonSetConditionFailure      = "SetConditionFailure"
 	reasonObjectConversionFailure  = "ObjectConversionFailure"
+	reasonInternalError            = "InternalError"
+	reasonTooManyEvents            = "TooManyEvents"
+
+	// Success event.
+	defaultSuccessEventMessage = "Status conditions were evaluated successfully"
+
+	// Template functions.
+	emptyIfMissingFunc = "emptyIfMissing"
+
+	// Message aggregation.
+	defaultAggregateSeparator = "; "
+
+	// Message truncation.
+	defaultMaxMessageLength        = 2048
+	defaultMaxMatchedMessageLength = 16384
+	ellipsis                       = "..."
+
+	// Health probe.
+	healthWindow         = 20
+	healthPanicThreshold = 10
+
+	// Condition format validation.
+	maxConditionTypeLength   = 316
+	maxConditionReasonLength = 1024
+
+	// Input.
+	inputAPIVersion = "function-status-transformer.fn.crossplane.io/v1beta1"
+	inputKind       = "StatusTransformation"
 
 	// Context keys.
 	logKey contextKey = "log"
 
+	// Function context keys.
+	defaultEnvironmentContextKey = "apiextensions.crossplane.io/environment"
+
+	// Template keys.
+	environmentTemplateKey        = "Env"
+	matchedResourcesTemplateKey   = "MatchedResources"
+	unmatchedResourcesTemplateKey = "UnmatchedResources"
+	resourceTemplateKey           = "Resource"
+	matchesPercentTemplateKey     = "MatchesPercent"
+	resourceCountTemplateKey      = "ResourceCount"
+	podEnvTemplateKey             = "PodEnv"
+	conditionCountTemplateKey     = "ResourceConditionCount"
+	xrTemplateKey                 = "XR"
+	xrNameTemplateKey             = "XRName"
+	xrNamespaceTemplateKey        = "XRNamespace"
+	capturesTemplateKey           = "Captures"
+	positionalGroupPrefix         = "_"
+
+	// Labels.
+	labelClaimNamespace = "crossplane.io/claim-namespace"
+
 	// Reserved keys.
 	reservedKeyPrefix    = "function-status-transformer.reserved-keys."
 	compositeResourceKey = reservedKeyPrefix + "composite-resource"
+	extraResourceKey     = reservedKeyPrefix + "extra-resources"
 )
 
 // Function returns whatever response you ask it to.
 type Function struct {
 	fnv1.UnimplementedFunctionRunnerServiceServer
 
-	log logging.Logger
+	log   logging.Logger
+	clock clock.PassiveClock
+
+	// Whether unknown input fields are ignored with a warning rather than
+	// rejected.
+	allowUnknownInputFields bool
+
+	// The allowlisted environment variables of the Function, available to
+	// templates.
+	podEnv map[string]string
+
+	// Whether debug logs are emitted. If so, every matcher of a hook is
+	// evaluated so that its result is logged.
+	debug bool
+
+	// Whether recent runs panicked, reported by the health probe.
+	health runHealth
+}
+
+// runHealth records whether each of the most recent runs panicked.
+type runHealth struct {
+	mu       sync.Mutex
+	panicked [healthWindow]bool
+	next     int
+	panics   int
+}
+
+// record records whether a run panicked, forgetting the oldest run once
+// healthWindow runs have been recorded.
+func (h *runHealth) record(panicked bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.panicked[h.next] {
+		h.panics--
+	}
+	if panicked {
+		h.panics++
+	}
+	h.panicked[h.next] = panicked
+	h.next = (h.next + 1) % healthWindow
+}
+
+// healthy returns false if at least healthPanicThreshold of the most recent
+// healthWindow runs panicked.
+func (h *runHealth) healthy() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.panics < healthPanicThreshold
+}
+
+// now returns the current time according to the Function's clock.
+func (f *Function) now() time.Time {
+	if f.clock == nil {
+		return time.Now()
+	}
+	return f.clock.Now()
+}
+
+// RunFunction runs the Function. A panic is recovered and reported on the
+// StatusTransformationSuccess condition, and recorded for the health probe.
+func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest) (rsp *fnv1.RunFunctionResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.health.record(true)
+			f.log.Info("recovered from panic while running function", "panic", r)
+			f.log.Debug("stack of recovered panic", "stack", string(debug.Stack()))
+			rsp, err = response.To(req, response.DefaultTTL), nil
+			response.ConditionFalse(rsp, typeFunctionSuccess, reasonInternalError).
+				WithMessage(fmt.Sprintf("recovered from panic: %v", r))
+		}
+	}()
+
+	rsp, err = f.runFunction(ctx, req)
+	f.health.record(false)
+	return rsp, err
+}
+
+// ServeHTTP serves a health probe. It responds with 503 Service Unavailable if
+// too many recent runs panicked, and 200 OK otherwise. A single composition
+// that causes a panic doesn't make the Function unhealthy.
+func (f *Function) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if !f.health.healthy() {
+		http.Error(w, "too many recent runs panicked", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
 }
 
-// RunFunction runs the Function.
-func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, error) {
+func (f *Function) runFunction(ctx context.Context, req *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, error) {
 	log := f.log.WithValues("tag", req.GetMeta().GetTag())
 	log.Debug("running function")
 
 	rsp := response.To(req, response.DefaultTTL)
 
-	in := &v1beta1.StatusTransformation{}
-	if err := request.GetInput(req, in); err != nil {
+	in, warnings, err := getInput(req, f.allowUnknownInputFields)
+	if err != nil {
 		msg := fmt.Sprintf("cannot get Function input from %T", req)
 		log.Info(msg, "error", err)
 		response.ConditionFalse(rsp, typeFunctionSuccess, reasonInputFailure).
 			WithMessage(errors.Wrap(err, msg).Error())
 		return rsp, nil
 	}
+	for _, w := range warnings {
+		log.Info("ignoring unknown Function input fields", "warning", w)
+		response.Warning(rsp, errors.Errorf("ignoring unknown Function input fields: %s", w))
+	}
+	if in.LogLevel != nil {
+		log = levelLogger{Logger: log, level: *in.LogLevel}
+	}
 
 	xr, err := request.GetObservedCompositeResource(req)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get observed XR from %T", req)
 		log.Info(msg, "error", err)
-		response.ConditionFalse(rsp, typeFunctionSuccess, reasonInputFailure).
-			WithMessage(errors.Wrap(err, msg).Error())
+		setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: errors.Wrap(err, msg)})
 		return rsp, nil
 	}
 	log = log.WithValues(
@@ -82,41 +245,225 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 	)
 	log.Info("running function")
 
-	observed := map[string]*fnv1.Resource{}
-	if req.GetObserved() != nil && req.GetObserved().GetResources() != nil {
-		observed = req.GetObserved().GetResources()
+	// Convert each resource at most once, so that matchers selecting the same
+	// resources share the converted objects.
+	observed := convertResources(req.GetObserved().GetResources())
+	extra := convertResources(getExtraResources(req))
+
+	if keys := reservedKeys(observed); len(keys) > 0 {
+		// These resources can never be matched by name. Say so rather than
+		// silently ignoring them.
+		log.Info("ignoring observed resources with reserved keys", "keys", keys)
+		response.Warning(rsp, errors.Errorf("ignoring observed resources whose keys start with the reserved prefix %q: %s", reservedKeyPrefix, strings.Join(keys, ", ")))
+	}
+
+	if in.ResourceSelector != nil {
+		observed, err = filterResources(observed, *in.ResourceSelector)
+		if err != nil {
+			log.Info("cannot filter observed resources", "error", err)
+			setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: errors.Wrap(err, "cannot filter observed resources")})
+			return rsp, nil
+		}
+	}
+
+	env, err := getEnvironment(req, ptr.Deref(in.EnvironmentContextKey, defaultEnvironmentContextKey))
+	switch {
+	case err != nil && in.EnvironmentContextKey == nil:
+		// Another function may use the default key for something else, so
+		// only templates that use Env are affected.
+		log.Info("cannot get environment, templates will see an empty environment", "error", err)
+	case err != nil:
+		log.Info("cannot get environment", "error", err)
+		setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: err})
+		return rsp, nil
+	}
+
+	if in.HooksContextKey != nil {
+		hooks, err := getContextHooks(req, *in.HooksContextKey)
+		if err != nil {
+			log.Info("cannot get hooks from function context", "error", err)
+			setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: err})
+			return rsp, nil
+		}
+		in.StatusConditionHooks = append(in.StatusConditionHooks, hooks...)
+	}
+
+	in.StatusConditionHooks, err = resolveMatcherRefs(in.StatusConditionHooks, in.NamedMatchers)
+	if err != nil {
+		log.Info("cannot resolve matcher references", "error", err)
+		setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: err})
+		return rsp, nil
+	}
+
+	in.StatusConditionHooks, err = resolveConditionRefs(in.StatusConditionHooks, in.NamedConditions)
+	if err != nil {
+		log.Info("cannot resolve condition references", "error", err)
+		setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: err})
+		return rsp, nil
+	}
+
+	severities, err := statusToSeverity(in.StatusToSeverity)
+	if err != nil {
+		log.Info("cannot parse statusToSeverity", "error", err)
+		setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: err})
+		return rsp, nil
+	}
+
+	opts := matchOptions{
+		onGroupConflict:         ptr.Deref(in.OnGroupConflict, v1beta1.GroupConflictOverwrite),
+		maxMatchedMessageLength: ptr.Deref(in.MaxMatchedMessageLength, defaultMaxMatchedMessageLength),
+		now:                     f.now(),
+		composite:               xr.Resource,
 	}
+	topts := transformOptions{
+		maxMessageLength:        ptr.Deref(in.MaxMessageLength, defaultMaxMessageLength),
+		validateConditionFormat: ptr.Deref(in.ValidateConditionFormat, false),
+		statusToSeverity:        severities,
+		messagePrefix:           ptr.Deref(in.MessagePrefix, ""),
+		messageSuffix:           ptr.Deref(in.MessageSuffix, ""),
+	}
+
+	dxr, err := request.GetDesiredCompositeResource(req)
+	if err != nil {
+		msg := fmt.Sprintf("cannot get desired XR from %T", req)
+		log.Info(msg, "error", err)
+		setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: errors.Wrap(err, msg)})
+		return rsp, nil
+	}
+	opts.desired = desiredResources(req.GetDesired().GetResources(), dxr)
 
 	errored := false
-	conditionsSet := map[string]bool{}
+	// Whether to evaluate every matcher of a hook, so that the result of each
+	// is logged even after one did not match.
+	var evaluateAll bool
+	switch ptr.Deref(in.LogLevel, "") {
+	case v1beta1.LogLevelDebug:
+		evaluateAll = true
+	case v1beta1.LogLevelInfo:
+		evaluateAll = false
+	default:
+		evaluateAll = f.debug
+	}
+	// The shortest TTL suggested by a matched hook, if any.
+	var ttl *time.Duration
+	conditionsSet := map[conditionKey]bool{}
+	out := &hookOutputs{rsp: rsp, in: in, xr: xr, opts: topts, matchOpts: opts, conditionsSet: conditionsSet, maxEvents: ptr.Deref(in.MaxEvents, 0)}
+	if ptr.Deref(in.RespectDesiredConditions, false) {
+		for _, t := range conditionTypes(dxr.Resource) {
+			log.Debug("condition already set on desired XR", "conditionType", t)
+			// The condition is already set on the composite, so it is
+			// already set for every target.
+			conditionsSet[conditionKey{conditionType: t, target: fnv1.Target_TARGET_COMPOSITE}] = true
+			conditionsSet[conditionKey{conditionType: t, target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM}] = true
+		}
+	}
 	for shi, sh := range in.StatusConditionHooks {
 		log := log.WithValues("statusConditionHookIndex", shi)
+		if sh.Name != nil {
+			log = log.WithValues("statusConditionHookName", *sh.Name)
+		}
+
+		enabled, err := hookEnabled(sh, env, f.podEnv)
+		if err != nil {
+			log.Info("cannot determine whether hook is enabled", "error", err)
+			setFailure(log, rsp, in, reasonInputFailure, &InputError{Err: errors.Wrapf(err, "cannot determine whether hook is enabled, statusConditionHookIndex: %d", shi)})
+			errored = true
+			continue
+		}
+		if !enabled {
+			log.Debug("skipping because hook is not enabled")
+			continue
+		}
+
 		// The regular expression groups found in the matches.
 		scGroups := map[string]string{}
+		// The regular expression groups found by each named matcher.
+		matcherGroups := map[string]map[string]string{}
+		// The resources selected by the matchers.
+		selected := map[string]conditionedObject{}
+		// The resources that matched, and those that were selected but did
+		// not match.
+		var matchedResources, unmatchedResources []matchedResource
+		// The percentage of resources that matched the last counting matcher.
+		var matchesPercent *float64
+		// The number of resources selected by the last matcher that compares it.
+		var resourceCount *int
 		allMatched := false
+		// Whether an earlier matcher did not match, so the hook cannot match.
+		failed := false
 		for mci, mc := range sh.Matchers {
 			log := log.WithValues("matchConditionIndex", mci)
+			if mc.Name != nil {
+				log = log.WithValues("matcherName", *mc.Name)
+			}
 			ctx := context.WithValue(ctx, logKey, log)
 
-			matched, mcGroups, err := matchResources(ctx, mc, observed, xr)
-			if err != nil {
+			mr, err := matchResources(ctx, mc, observed, extra, xr, opts)
+			matched := mr.matched
+			switch {
+			case err != nil && failed:
+				// This matcher is only evaluated to log its result, see
+				// evaluateAll. The hook cannot match, so the error does not
+				// fail the function.
+				log.Info("cannot match resources after the hook failed to match", "error", err)
+				matched = false
+			case err != nil && !ptr.Deref(in.StrictMatching, true):
+				// Treat the failure as a non-match.
+				log.Info("cannot match resources, treating as not matched", "error", err)
+				matched = false
+			case err != nil:
 				log.Info("cannot match resources", "error", err)
-				response.ConditionFalse(rsp, typeFunctionSuccess, reasonMatchFailure).
-					WithMessage(errors.Wrapf(err, "cannot match resources, statusConditionHookIndex: %d, matchConditionIndex: %d", shi, mci).Error())
+				setFailure(log, rsp, in, reasonMatchFailure, &MatchError{Op: "cannot match resources", HookIndex: shi, MatcherIndex: mci, Err: err})
 				matched = false
 				errored = true
 			}
 
+			// The resources that did not match are collected whether or not
+			// the matcher matched, so that the resources that held up a
+			// matcher requiring all resources to match are logged.
+			um := withComposite(unmatched(mr, mc.Conditions), opts.composite)
+			unmatchedResources = append(unmatchedResources, um...)
+
+			log.Debug("evaluated matcher", "matched", matched, "unmatchedResources", resourceKeys(um))
+			if failed {
+				continue
+			}
 			if !matched {
 				// All matchConditions must match.
 				allMatched = false
-				break
+				if !evaluateAll {
+					break
+				}
+				failed = true
+				continue
 			}
 			allMatched = true
 
 			// All matches were successful, copy over any regex groups.
-			for k, v := range mcGroups {
-				scGroups[k] = v
+			if err := mergeGroups(scGroups, mr.groups, opts.onGroupConflict); err != nil {
+				allMatched = false
+				if !ptr.Deref(in.StrictMatching, true) {
+					// Treat the conflict as a non-match and skip the hook.
+					log.Info("cannot merge capture groups, treating as not matched", "error", err)
+					break
+				}
+				log.Info("cannot merge capture groups", "error", err)
+				setFailure(log, rsp, in, reasonMatchFailure, &MatchError{Op: "cannot merge capture groups", HookIndex: shi, MatcherIndex: mci, Err: err})
+				errored = true
+				break
+			}
+			if mc.Name != nil {
+				matcherGroups[*mc.Name] = mr.groups
+			}
+			for k, v := range mr.resources {
+				selected[k] = v
+			}
+			matchedResources = append(matchedResources, withComposite(mr.matchedResources, opts.composite)...)
+			if mr.matchesPercent != nil {
+				matchesPercent = mr.matchesPercent
+			}
+			if mr.resourceCount != nil {
+				resourceCount = mr.resourceCount
 			}
 		}
 
@@ -125,294 +472,2434 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1.RunFunctionRequest
 			continue
 		}
 
-		// All matchConditions matched, set the desired conditions.
-		for sci, cs := range sh.SetConditions {
-			log := log.WithValues("setConditionIndex", sci)
-			if conditionsSet[cs.Condition.Type] && (cs.Force == nil || !*cs.Force) {
-				// The condition is already set and this setter is not forceful.
-				log.Debug("skipping because condition is already set and setCondition is not forceful")
-				continue
-			}
-			log.Debug("setting condition")
+		if sh.GracePeriod != nil && inGracePeriod(selected, sh.GracePeriod.Duration, f.now()) {
+			// Do not alarm on resources that were only just created.
+			log.Debug("skipping because a selected resource is within the grace period")
+			continue
+		}
+		if sh.TTL != nil && (ttl == nil || sh.TTL.Duration < *ttl) {
+			ttl = ptr.To(sh.TTL.Duration)
+		}
+		values := templateValues(scGroups, matcherGroups, env, f.podEnv, xr.Resource, matchedResources, unmatchedResources, matchesPercent, resourceCount)
 
-			c, err := transformCondition(cs, scGroups)
-			if err != nil {
-				log.Info("cannot set condition", "error", err)
-				response.ConditionFalse(rsp, typeFunctionSuccess, reasonSetConditionFailure).
-					WithMessage(errors.Wrapf(err, "cannot set condition, statusConditionHookIndex: %d, setConditionIndex: %d", shi, sci).Error())
-				errored = true
-				continue
-			}
+		// All matchConditions matched, set the desired conditions and
+		// create the events.
+		eventsCreated := out.eventsCreated
+		if !out.apply(log, HookLocation{Index: shi}, sh.SetConditions, sh.CreateEvents, values, matchedResources) {
+			errored = true
+		}
+		if sh.EventTTL != nil && out.eventsCreated > eventsCreated && (ttl == nil || sh.EventTTL.Duration < *ttl) {
+			ttl = ptr.To(sh.EventTTL.Duration)
+		}
+	}
 
+	if in.ReadinessRollup != nil {
+		log := log.WithValues("readinessRollup", true)
+		ctx := context.WithValue(ctx, logKey, log)
+		c, err := rollupReadiness(ctx, *in.ReadinessRollup, observed, xr, topts)
+		switch {
+		case err != nil:
+			log.Info("cannot roll up readiness", "error", err)
+			setFailure(log, rsp, in, reasonMatchFailure, &RollupError{Err: err})
+			errored = true
+		case conditionsSet[conditionKey{conditionType: c.Type, target: c.GetTarget()}]:
+			// Conditions set by hooks take precedence.
+			log.Debug("skipping because condition is already set")
+		default:
 			rsp.Conditions = append(rsp.Conditions, c)
-			conditionsSet[cs.Condition.Type] = true
+			conditionsSet[conditionKey{conditionType: c.Type, target: c.GetTarget()}] = true
 		}
+	}
 
-		for cei, ce := range sh.CreateEvents {
-			log := log.WithValues("createEventIndex", cei)
-			r, err := transformEvent(ce, scGroups)
-			if err != nil {
-				log.Info("cannot create event")
-				response.ConditionFalse(rsp, typeFunctionSuccess, reasonSetConditionFailure).
-					WithMessage(errors.Wrapf(err, "cannot create event, statusConditionHookIndex: %d, createEventIndex: %d", shi, cei).Error())
-				errored = true
-				continue
-			}
-
-			rsp.Results = append(rsp.Results, r)
+	for phi, ph := range in.WhenAllHooksEvaluated {
+		log := log.WithValues("whenAllHooksEvaluatedIndex", phi)
+		if ph.Name != nil {
+			log = log.WithValues("whenAllHooksEvaluatedName", *ph.Name)
+		}
+		if !conditionsSetMatch(conditionsSet, ph.WhenSet, true) || !conditionsSetMatch(conditionsSet, ph.WhenNotSet, false) {
+			log.Debug("skipping because the conditions set by hooks did not match")
+			continue
+		}
+		values := templateValues(nil, nil, env, f.podEnv, xr.Resource, nil, nil, nil, nil)
+		if !out.apply(log, HookLocation{Index: phi, AfterAllHooks: true}, ph.SetConditions, ph.CreateEvents, values, nil) {
+			errored = true
 		}
 	}
 
-	if !errored {
-		response.ConditionTrue(rsp, typeFunctionSuccess, reasonAvailable)
+	if out.eventsDropped > 0 {
+		log.Info("not all events were created", "eventsDropped", out.eventsDropped, "maxEvents", out.maxEvents)
+		response.Warning(rsp, errors.Errorf("%d more events were not created, maxEvents: %d", out.eventsDropped, out.maxEvents)).
+			WithReason(reasonTooManyEvents)
 	}
 
-	return rsp, nil
-}
-
-func matchResources(ctx context.Context, mc v1beta1.Matcher, observedMap map[string]*fnv1.Resource, xr *sdkresource.Composite) (bool, map[string]string, error) {
-	log := ctx.Value(logKey).(logging.Logger)
+	// An input without hooks is usually a misconfiguration.
+	empty := len(in.StatusConditionHooks) == 0 && len(in.WhenAllHooksEvaluated) == 0 && in.ReadinessRollup == nil
+	if empty && ptr.Deref(in.WarnOnEmpty, false) {
+		log.Info("no hooks are configured")
+		response.Warning(rsp, errors.New("no hooks are configured")).WithReason(reasonNoHooks)
+	}
 
-	rs := map[string]conditionedObject{}
-	for i, r := range mc.Resources {
-		re, err := regexp.Compile(r.Name)
+	if !errored && ptr.Deref(in.EmitSuccessEvent, false) {
+		msg, err := templateMessage(ptr.To(ptr.Deref(in.SuccessEventMessage, defaultSuccessEventMessage)), templateValues(nil, nil, env, f.podEnv, xr.Resource, nil, nil, nil, nil))
 		if err != nil {
-			log.Info("cannot compile resource key regex", "resourcesIndex", i, "error", err)
-			return false, nil, errors.Wrapf(err, "cannot compile resource key regex, resourcesIndex: %d", i)
-		}
-		for k, v := range observedMap {
-			if re.MatchString(k) {
-				u := &composed.Unstructured{}
-				if err := sdkresource.AsObject(v.GetResource(), u); err != nil {
-					log.Info("cannot convert resource to object", "resourcesIndex", i, "observedMapKey", k, "error", err)
-					return false, nil, errors.Wrapf(err, "cannot convert resource to object, resourcesIndex: %d, observedMapKey: %s", i, k)
-				}
-				rs[k] = u
-			}
+			log.Info("cannot render success event message", "error", err)
+			setFailure(log, rsp, in, reasonSetConditionFailure, &SuccessEventError{Err: err})
+			errored = true
+		} else {
+			response.Normal(rsp, *msg).WithReason(reasonAvailable)
 		}
 	}
 
-	if ptr.Deref(mc.IncludeCompositeAsResource, false) {
-		// The user wants to match against conditions of the composite resource.
-		rs[compositeResourceKey] = xr.Resource
+	if !errored && ptr.Deref(in.EmitSuccessCondition, true) {
+		reason := reasonAvailable
+		if ptr.Deref(in.ReportNoMatch, false) && out.conditionsAdded == 0 {
+			log.Debug("no hook set a condition")
+			reason = reasonNoMatch
+		}
+		if ptr.Deref(in.WarnOnEmpty, false) && empty {
+			reason = reasonNoHooks
+		}
+		targetSuccessCondition(response.ConditionTrue(rsp, typeFunctionSuccess, reason), in)
 	}
 
-	if len(rs) == 0 {
-		// There are no resources to match against.
-		return false, nil, nil
-	}
-	if len(mc.Conditions) == 0 {
-		// There are no conditions to match against.
-		return false, nil, nil
+	if ttl != nil {
+		log.Debug("using the shortest TTL suggested by a matched hook", "ttl", *ttl)
+		rsp.Meta.Ttl = durationpb.New(*ttl)
 	}
 
-	switch ptr.Deref(mc.Type, v1beta1.AllResourcesMatchAllConditions) {
-	case v1beta1.AnyResourceMatchesAnyCondition:
-		return anyResourceMatchesAnyCondition(ctx, mc.Conditions, rs)
-	case v1beta1.AnyResourceMatchesAllConditions:
-		return anyResourceMatchesAllConditions(ctx, mc.Conditions, rs)
-	case v1beta1.AllResourcesMatchAnyCondition:
-		return allResourcesMatchAnyConditions(ctx, mc.Conditions, rs)
-	case v1beta1.AllResourcesMatchAllConditions:
-		fallthrough
-	default:
-		return allResourcesMatchAllConditions(ctx, mc.Conditions, rs)
+	// Forceful setConditions of different hooks can set the same condition
+	// more than once.
+	rsp.Conditions = coalesceConditions(rsp.Conditions)
+
+	if ptr.Deref(in.SortConditions, false) {
+		sortConditions(rsp.Conditions)
 	}
-}
 
-func anyResourceMatchesAnyCondition(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject) (bool, map[string]string, error) {
-	log := ctx.Value(logKey).(logging.Logger)
-	for k, r := range rm {
-		for cmi, cm := range cms {
-			log := log.WithValues("resource", k, "conditionIndex", cmi)
-			ctx := context.WithValue(ctx, logKey, log)
-			m, cg, err := match(ctx, cm, r)
-			if err != nil {
-				log.Info("cannot match resource", "error", err)
-				return false, nil, err
-			}
+	return rsp, nil
+}
 
-			if m {
-				return true, cg, nil
-			}
+// coalesceConditions returns the supplied conditions without exact duplicates,
+// keeping the last of each. Crossplane applies conditions in order, so keeping
+// the first could let a different condition set in between take effect.
+func coalesceConditions(cs []*fnv1.Condition) []*fnv1.Condition {
+	out := make([]*fnv1.Condition, 0, len(cs))
+	for i, c := range cs {
+		if !slices.ContainsFunc(cs[i+1:], func(o *fnv1.Condition) bool { return proto.Equal(o, c) }) {
+			out = append(out, c)
 		}
 	}
-
-	return false, nil, nil
+	return out
 }
 
-func anyResourceMatchesAllConditions(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject) (bool, map[string]string, error) {
-	log := ctx.Value(logKey).(logging.Logger)
-	capturedGroups := map[string]string{}
-	for k, r := range rm {
-		matched := 0
-		for cmi, cm := range cms {
-			log := log.WithValues("resource", k, "conditionIndex", cmi)
-			ctx := context.WithValue(ctx, logKey, log)
-			m, cg, err := match(ctx, cm, r)
-			if err != nil {
-				log.Info("cannot match resource", "error", err)
-				return false, nil, err
-			}
-			if !m {
-				break
-			}
-			matched++
-			for k, v := range cg {
-				capturedGroups[k] = v
-			}
+// sortConditions sorts the supplied conditions by type and then target, so
+// that the order does not depend on the order of hooks. The
+// StatusTransformationSuccess condition is always sorted last.
+func sortConditions(cs []*fnv1.Condition) {
+	sort.SliceStable(cs, func(i, j int) bool {
+		si, sj := cs[i].GetType() == typeFunctionSuccess, cs[j].GetType() == typeFunctionSuccess
+		if si != sj {
+			return sj
+		}
+		if cs[i].GetType() != cs[j].GetType() {
+			return cs[i].GetType() < cs[j].GetType()
 		}
-		if matched == len(cms) {
-			return true, capturedGroups, nil
+		return cs[i].GetTarget() < cs[j].GetTarget()
+	})
+}
+
+// conditionsSetMatch reports whether each of the supplied condition types was
+// set for any target (set is true), or was not set for any target (set is
+// false).
+func conditionsSetMatch(conditionsSet map[conditionKey]bool, types []string, set bool) bool {
+	for _, t := range types {
+		isSet := conditionsSet[conditionKey{conditionType: t, target: fnv1.Target_TARGET_COMPOSITE}] ||
+			conditionsSet[conditionKey{conditionType: t, target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM}]
+		if isSet != set {
+			return false
 		}
 	}
+	return true
+}
+
+// hookOutputs sets the conditions and creates the events of hooks. It tracks
+// which conditions were set.
+type hookOutputs struct {
+	rsp       *fnv1.RunFunctionResponse
+	in        *v1beta1.StatusTransformation
+	xr        *sdkresource.Composite
+	opts      transformOptions
+	matchOpts matchOptions
+
+	conditionsSet map[conditionKey]bool
+
+	// The number of conditions set by the hooks.
+	conditionsAdded int
 
-	return false, nil, nil
+	// The maximum number of events to create. Zero or less is unlimited.
+	maxEvents     int
+	eventsCreated int
+	eventsDropped int
 }
 
-func allResourcesMatchAnyConditions(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject) (bool, map[string]string, error) {
-	log := ctx.Value(logKey).(logging.Logger)
-	capturedGroups := map[string]string{}
-	for k, r := range rm {
-		matched := 0
-		for cmi, cm := range cms {
-			log := log.WithValues("resource", k, "conditionIndex", cmi)
-			ctx := context.WithValue(ctx, logKey, log)
-			m, cg, err := match(ctx, cm, r)
-			if err != nil {
-				log.Info("cannot match resource", "error", err)
-				return false, nil, err
-			}
-			if !m {
-				continue
-			}
-			matched++
-			for k, v := range cg {
-				capturedGroups[k] = v
-			}
+// apply sets the supplied conditions and creates the supplied events using the
+// supplied template values. The location identifies the hook in failure
+// messages. It returns false if any condition or event failed.
+func (o *hookOutputs) apply(log logging.Logger, location HookLocation, scs []v1beta1.SetCondition, ces []v1beta1.CreateEvent, values map[string]any, matched []matchedResource) bool {
+	ok := true
+	for sci, cs := range scs {
+		log := log.WithValues("setConditionIndex", sci)
+		t := setConditionType(cs)
+		if t == typeFunctionSuccess {
+			// The function sets this condition itself. Setting it here too
+			// would produce a confusing duplicate.
+			log.Info("ignoring setCondition of a reserved condition type", "conditionType", t)
+			response.Warning(o.rsp, errors.Errorf("ignoring setCondition of reserved condition type %s, %s, setConditionIndex: %d", t, location, sci))
+			continue
 		}
-		if matched == 0 {
-			return false, nil, nil
+		key := conditionKey{conditionType: t, target: *transformTarget(cs.Target)}
+		if o.conditionsSet[key] && (cs.Force == nil || !*cs.Force) {
+			// The condition is already set and this setter is not forceful.
+			log.Debug("skipping because condition is already set and setCondition is not forceful")
+			continue
 		}
-	}
 
-	return true, capturedGroups, nil
-}
+		met, err := preconditionsMet(log, cs.Preconditions, o.xr.Resource, o.matchOpts)
+		if err != nil {
+			log.Info("cannot match preconditions", "error", err)
+			setFailure(log, o.rsp, o.in, reasonSetConditionFailure, &SetConditionError{Op: "cannot match preconditions", Hook: location, SetConditionIndex: sci, Err: err})
+			ok = false
+			continue
+		}
+		if !met {
+			log.Debug("skipping because the composite resource did not match the preconditions")
+			continue
+		}
+		log.Debug("setting condition")
 
-func allResourcesMatchAllConditions(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject) (bool, map[string]string, error) {
-	log := ctx.Value(logKey).(logging.Logger)
-	capturedGroups := map[string]string{}
-	for k, r := range rm {
-		for cmi, cm := range cms {
-			log := log.WithValues("resource", k, "conditionIndex", cmi)
-			ctx := context.WithValue(ctx, logKey, log)
-			m, cg, err := match(ctx, cm, r)
-			if err != nil {
-				log.Info("cannot match resource", "error", err)
-				return false, nil, err
-			}
-			if !m {
-				return false, nil, nil
-			}
-			for k, v := range cg {
-				capturedGroups[k] = v
-			}
+		var c *fnv1.Condition
+		if cs.CopyCondition != nil {
+			c, err = copyCondition(cs, matched, o.opts)
+		} else {
+			c, err = transformCondition(cs, values, matched, o.opts)
+		}
+		if err != nil {
+			log.Info("cannot set condition", "error", err)
+			setFailure(log, o.rsp, o.in, reasonSetConditionFailure, &SetConditionError{Op: "cannot set condition", Hook: location, SetConditionIndex: sci, Err: err})
+			ok = false
+			continue
+		}
+		if c == nil {
+			log.Debug("skipping because no matched resource has the condition to copy")
+			continue
+		}
+
+		if ptr.Deref(cs.PreserveTransitionTime, false) {
+			preserveTransitionTime(c, o.xr.Resource)
 		}
+
+		o.rsp.Conditions = append(o.rsp.Conditions, c)
+		o.conditionsAdded++
+		o.conditionsSet[key] = true
 	}
 
-	return true, capturedGroups, nil
-}
+	for cei, ce := range ces {
+		log := log.WithValues("createEventIndex", cei)
+		r, err := transformEvent(ce, values, matched, o.opts)
+		if err != nil {
+			log.Info("cannot create event")
+			setFailure(log, o.rsp, o.in, reasonSetConditionFailure, &CreateEventError{Hook: location, CreateEventIndex: cei, Err: err})
+			ok = false
+			continue
+		}
 
-func match(ctx context.Context, cm v1beta1.ConditionMatcher, co conditionedObject) (bool, map[string]string, error) {
-	log := ctx.Value(logKey).(logging.Logger)
-	cmGroups := map[string]string{}
+		if o.maxEvents > 0 && o.eventsCreated >= o.maxEvents {
+			log.Debug("skipping because the maximum number of events was created")
+			o.eventsDropped++
+			continue
+		}
 
-	c := co.GetCondition(xpv1.ConditionType(cm.Type))
-	if cm.Reason != nil && *cm.Reason != string(c.Reason) {
-		log.Debug(fmt.Sprintf("condition reason \"%s\" did not match \"%s\"", c.Reason, *cm.Reason))
-		return false, nil, nil
+		o.rsp.Results = append(o.rsp.Results, r)
+		o.eventsCreated++
 	}
+	return ok
+}
 
-	if cm.Status != nil && *cm.Status != metav1.ConditionStatus(c.Status) {
-		log.Debug(fmt.Sprintf("condition status \"%s\" did not match \"%s\"", c.Status, *cm.Status))
-		return false, nil, nil
+// rollupReadiness returns a condition that is True when every selected
+// resource has all of the rollup's condition types set to True. Otherwise the
+// condition is False and its message lists the unready resources. If the
+// rollup is Unknown while creating, the condition is instead Unknown when none
+// of the unready resources is failing.
+func rollupReadiness(ctx context.Context, rr v1beta1.ReadinessRollup, observed map[string]convertedResource, xr *sdkresource.Composite, topts transformOptions) (*fnv1.Condition, error) {
+	mc := v1beta1.Matcher{Resources: rr.Resources}
+	if len(mc.Resources) == 0 {
+		mc.Resources = []v1beta1.ResourceMatcher{{Name: ".*"}}
 	}
-
-	if cm.Message == nil {
-		log.Debug("condition matched")
-		return true, nil, nil
+	types := rr.ConditionTypes
+	if len(types) == 0 {
+		types = []string{string(xpv1.TypeSynced), string(xpv1.TypeReady)}
+	}
+	for _, t := range types {
+		mc.Conditions = append(mc.Conditions, v1beta1.ConditionMatcher{Type: t, Status: ptr.To(metav1.ConditionTrue)})
 	}
 
-	// Match the message and build up a map of template arguments.
-	re, err := regexp.Compile(*cm.Message)
+	rs, err := selectResources(ctx, mc, observed, nil, xr)
 	if err != nil {
-		return false, nil, errors.Wrap(err, "cannot compile message regex")
+		return nil, err
 	}
-
-	matches := re.FindStringSubmatch(c.Message)
-	if len(matches) == 0 {
-		log.Debug(fmt.Sprintf("condition message \"%s\" did not match \"%s\"", c.Message, *cm.Message))
-		return false, nil, nil
+	creatingReasons := rr.CreatingReasons
+	if len(creatingReasons) == 0 {
+		creatingReasons = []string{string(xpv1.ReasonCreating)}
 	}
-
-	for i := 1; i < len(matches); i++ {
-		cmGroups[re.SubexpNames()[i]] = matches[i]
+	var unready, failing []string
+	for _, k := range sortedKeys(rs) {
+		res, err := allResourcesMatchAllConditions(ctx, mc.Conditions, map[string]conditionedObject{k: rs[k]}, matchOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if !res.matched {
+			unready = append(unready, k)
+			if isFailing(rs[k], types, creatingReasons) {
+				failing = append(failing, k)
+			}
+		}
 	}
-	log.Debug(fmt.Sprintf("condition matched - total captured groups: %v", cmGroups))
 
-	return true, cmGroups, nil
-}
-
-func transformCondition(cs v1beta1.SetCondition, templateValues map[string]string) (*fnv1.Condition, error) {
 	c := &fnv1.Condition{
-		Type:   cs.Condition.Type,
+		Type:   ptr.Deref(rr.Type, string(xpv1.TypeReady)),
+		Status: fnv1.Status_STATUS_CONDITION_FALSE,
+		Reason: reasonUnavailable,
+		Target: transformTarget(rr.Target),
+	}
+	switch {
+	case len(rs) == 0:
+		c.Message = ptr.To("no resources are selected")
+	case ptr.Deref(rr.UnknownWhileCreating, false) && len(failing) > 0:
+		c.Message = ptr.To(truncateMessage("failing resources: "+strings.Join(failing, ", "), topts.maxMessageLength))
+	case ptr.Deref(rr.UnknownWhileCreating, false) && len(unready) > 0:
+		c.Status = fnv1.Status_STATUS_CONDITION_UNKNOWN
+		c.Reason = string(xpv1.ReasonCreating)
+		c.Message = ptr.To(truncateMessage("creating resources: "+strings.Join(unready, ", "), topts.maxMessageLength))
+	case len(unready) > 0:
+		c.Message = ptr.To(truncateMessage("unready resources: "+strings.Join(unready, ", "), topts.maxMessageLength))
+	default:
+		c.Status = fnv1.Status_STATUS_CONDITION_TRUE
+		c.Reason = reasonAvailable
+	}
+	return c, nil
+}
+
+// isFailing reports whether any of the supplied condition types of the
+// supplied resource is False with a reason that is not one of the supplied
+// creating reasons.
+func isFailing(r conditionedObject, types, creatingReasons []string) bool {
+	for _, t := range types {
+		c, ok := getCondition(r, xpv1.ConditionType(t))
+		if ok && c.Status == corev1.ConditionFalse && !slices.Contains(creatingReasons, string(c.Reason)) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionKey identifies a condition that has been set. The same condition
+// type can be set once for each target.
+type conditionKey struct {
+	conditionType string
+	target        fnv1.Target
+}
+
+// getInput returns the Function input. The input's apiVersion and kind are
+// checked before it is unmarshalled. Unknown fields are rejected unless
+// allowUnknownFields is true, in which case they are ignored and a warning
+// describing them is returned.
+func getInput(req *fnv1.RunFunctionRequest, allowUnknownFields bool) (*v1beta1.StatusTransformation, []string, error) {
+	in := &v1beta1.StatusTransformation{}
+	if req.GetInput() == nil {
+		return in, nil, nil
+	}
+
+	fields := req.GetInput().GetFields()
+	if kind := fields["kind"].GetStringValue(); kind != inputKind {
+		return nil, nil, errors.Errorf("unsupported input kind %q, expected %s", kind, inputKind)
+	}
+	if av := fields["apiVersion"].GetStringValue(); av != inputAPIVersion {
+		return nil, nil, errors.Errorf("unsupported input apiVersion %q for kind %s, expected %s", av, inputKind, inputAPIVersion)
+	}
+
+	err := request.GetInput(req, in)
+	if err == nil || !allowUnknownFields {
+		return in, nil, err
+	}
+
+	// Try again without rejecting unknown fields. The standard library ignores
+	// them.
+	b, merr := protojson.Marshal(req.GetInput())
+	if merr != nil {
+		return nil, nil, err
+	}
+	in = &v1beta1.StatusTransformation{}
+	if uerr := json.Unmarshal(b, in); uerr != nil {
+		// The input is invalid for some other reason.
+		return nil, nil, err
+	}
+	return in, []string{err.Error()}, nil
+}
+
+// setFailure records a failure on the StatusTransformationSuccess condition. If
+// the input asks for it, a Warning event describing the failure is also
+// created. The error is logged at debug level as is, so its type is preserved
+// for log sinks that inspect it.
+func setFailure(log logging.Logger, rsp *fnv1.RunFunctionResponse, in *v1beta1.StatusTransformation, reason string, err error) {
+	log.Debug("recording failure", "reason", reason, "error", err)
+	targetSuccessCondition(response.ConditionFalse(rsp, typeFunctionSuccess, reason), in).WithMessage(err.Error())
+	if ptr.Deref(in.EmitErrorEvents, false) {
+		response.Warning(rsp, err).WithReason(reason)
+	}
+}
+
+// targetSuccessCondition targets the supplied StatusTransformationSuccess
+// condition at the successConditionTarget requested by the input.
+func targetSuccessCondition(c *response.ConditionOption, in *v1beta1.StatusTransformation) *response.ConditionOption {
+	if *transformTarget(in.SuccessConditionTarget) == fnv1.Target_TARGET_COMPOSITE_AND_CLAIM {
+		return c.TargetCompositeAndClaim()
+	}
+	return c.TargetComposite()
+}
+
+// podEnv returns the values of the supplied environment variables that are
+// set, looked up with the supplied function.
+func podEnv(names []string, lookup func(string) (string, bool)) map[string]string {
+	env := make(map[string]string, len(names))
+	for _, name := range names {
+		if v, ok := lookup(name); ok {
+			env[name] = v
+		}
+	}
+	return env
+}
+
+// getEnvironment returns the environment stored in the function context under
+// the supplied key, if any.
+func getEnvironment(req *fnv1.RunFunctionRequest, key string) (map[string]any, error) {
+	v, ok := request.GetContextKey(req, key)
+	if !ok {
+		return nil, nil
+	}
+	env, ok := v.AsInterface().(map[string]any)
+	if !ok {
+		return nil, errors.Errorf("cannot get environment from function context key %s: not an object", key)
+	}
+	return env, nil
+}
+
+// resolveMatcherRefs returns the supplied hooks with the named matchers each
+// hook references prepended to its own matchers.
+func resolveMatcherRefs(hooks []v1beta1.StatusConditionHook, named map[string][]v1beta1.Matcher) ([]v1beta1.StatusConditionHook, error) {
+	resolved := make([]v1beta1.StatusConditionHook, len(hooks))
+	for shi, sh := range hooks {
+		if len(sh.MatcherRefs) > 0 {
+			var matchers []v1beta1.Matcher
+			for _, ref := range sh.MatcherRefs {
+				ms, ok := named[ref]
+				if !ok {
+					return nil, errors.Errorf("cannot find named matchers %q, statusConditionHookIndex: %d", ref, shi)
+				}
+				matchers = append(matchers, ms...)
+			}
+			sh.Matchers = append(matchers, sh.Matchers...)
+		}
+		resolved[shi] = sh
+	}
+	return resolved, nil
+}
+
+// resolveConditionRefs returns the supplied hooks with the named conditions
+// each matcher references prepended to its own conditions.
+func resolveConditionRefs(hooks []v1beta1.StatusConditionHook, named map[string][]v1beta1.ConditionMatcher) ([]v1beta1.StatusConditionHook, error) {
+	resolved := make([]v1beta1.StatusConditionHook, len(hooks))
+	for shi, sh := range hooks {
+		matchers := make([]v1beta1.Matcher, len(sh.Matchers))
+		for mci, mc := range sh.Matchers {
+			if len(mc.ConditionRefs) > 0 {
+				var conditions []v1beta1.ConditionMatcher
+				for _, ref := range mc.ConditionRefs {
+					cms, ok := named[ref]
+					if !ok {
+						return nil, errors.Errorf("cannot find named conditions %q, statusConditionHookIndex: %d, matchConditionIndex: %d", ref, shi, mci)
+					}
+					conditions = append(conditions, cms...)
+				}
+				mc.Conditions = append(conditions, mc.Conditions...)
+			}
+			matchers[mci] = mc
+		}
+		sh.Matchers = matchers
+		resolved[shi] = sh
+	}
+	return resolved, nil
+}
+
+// getContextHooks returns the status condition hooks stored in the function
+// context under the supplied key.
+func getContextHooks(req *fnv1.RunFunctionRequest, key string) ([]v1beta1.StatusConditionHook, error) {
+	v, ok := request.GetContextKey(req, key)
+	if !ok {
+		return nil, nil
+	}
+	if v.GetListValue() == nil {
+		return nil, errors.Errorf("cannot get hooks from function context key %s: not a list", key)
+	}
+	b, err := protojson.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get hooks from function context key %s", key)
+	}
+	var hooks []v1beta1.StatusConditionHook
+	if err := json.Unmarshal(b, &hooks); err != nil {
+		return nil, errors.Wrapf(err, "cannot get hooks from function context key %s", key)
+	}
+	return hooks, nil
+}
+
+// hookEnabled renders the hook's enabled template using the environment and
+// the allowlisted environment variables of the Function, and reports whether
+// the hook should be evaluated.
+func hookEnabled(sh v1beta1.StatusConditionHook, env map[string]any, podEnv map[string]string) (bool, error) {
+	if sh.Enabled == nil {
+		return true, nil
+	}
+	rendered, err := templateMessage(sh.Enabled, templateValues(nil, nil, env, podEnv, nil, nil, nil, nil, nil))
+	if err != nil {
+		return false, err
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(*rendered))
+	return enabled, errors.Wrapf(err, "cannot parse enabled %q as a boolean", *rendered)
+}
+
+// templateValues returns the values available to message templates. The
+// environment is available under the Env key, the composite resource under the
+// XR, XRName, and XRNamespace keys, the matched resources under the
+// MatchedResources key, the number of conditions of the first matched resource
+// under the ResourceConditionCount key, the resources that did not match under
+// the UnmatchedResources key, and the percentage of resources that matched
+// under the MatchesPercent key. The capture groups take precedence over all of
+// these, so that adding a key never changes what an existing template renders.
+// The capture groups of each named matcher are also available under the
+// matcher's name, which takes precedence over a capture group of the same name.
+func templateValues(groups map[string]string, matcherGroups map[string]map[string]string, env map[string]any, podEnv map[string]string, xr conditionedObject, matched, unmatched []matchedResource, matchesPercent *float64, resourceCount *int) map[string]any {
+	values := make(map[string]any, len(groups)+len(matcherGroups)+8)
+	// The groups are also available as a map, so that they can be looked up
+	// by a dynamic name with index.
+	captures := make(map[string]string, len(groups))
+	maps.Copy(captures, groups)
+	values[capturesTemplateKey] = captures
+	if env != nil {
+		values[environmentTemplateKey] = env
+	}
+	if podEnv != nil {
+		values[podEnvTemplateKey] = podEnv
+	}
+	if xr != nil {
+		values[xrTemplateKey] = xr.UnstructuredContent()
+		values[xrNameTemplateKey] = xr.GetName()
+		values[xrNamespaceTemplateKey] = xrNamespace(xr)
+	}
+	if len(matched) > 0 {
+		values[matchedResourcesTemplateKey] = matched
+		values[conditionCountTemplateKey] = matched[0].ConditionCount
+		values[resourceTemplateKey] = resourceValue(matched[0])
+	}
+	if len(unmatched) > 0 {
+		values[unmatchedResourcesTemplateKey] = unmatched
+	}
+	if matchesPercent != nil {
+		values[matchesPercentTemplateKey] = *matchesPercent
+	}
+	if resourceCount != nil {
+		values[resourceCountTemplateKey] = *resourceCount
+	}
+	for k, v := range groups {
+		values[k] = v
+	}
+	for name, g := range matcherGroups {
+		values[name] = g
+	}
+	return values
+}
+
+// xrNamespace returns the namespace of the claim of the supplied composite
+// resource. A composite resource without a claim has no claim namespace, so its
+// own namespace is returned instead, which is empty for cluster scoped
+// composite resources.
+func xrNamespace(xr conditionedObject) string {
+	if ns := xr.GetLabels()[labelClaimNamespace]; ns != "" {
+		return ns
+	}
+	return xr.GetNamespace()
+}
+
+// getExtraResources returns the extra resources supplied in the request, keyed
+// by the name they were requested under and their index.
+func getExtraResources(req *fnv1.RunFunctionRequest) map[string]*fnv1.Resource {
+	extra := map[string]*fnv1.Resource{}
+	for name, rs := range req.GetExtraResources() {
+		for i, r := range rs.GetItems() {
+			extra[fmt.Sprintf("%s.%s.%d", extraResourceKey, name, i)] = r
+		}
+	}
+	return extra
+}
+
+// convertedResource is a resource converted to an object, or the error
+// encountered while converting it.
+type convertedResource struct {
+	object conditionedObject
+	err    error
+
+	// The kind of the resource, which is known even if it could not be
+	// converted.
+	kind string
+
+	// The connection details observed for the resource.
+	connectionDetails map[string][]byte
+}
+
+// convertResources converts the supplied resources to objects. Conversion
+// errors are recorded rather than returned so that they are only surfaced by
+// the matchers that select the failing resource.
+func convertResources(rs map[string]*fnv1.Resource) map[string]convertedResource {
+	converted := make(map[string]convertedResource, len(rs))
+	for k, v := range rs {
+		u := &composed.Unstructured{}
+		if err := sdkresource.AsObject(v.GetResource(), u); err != nil {
+			converted[k] = convertedResource{err: err, kind: v.GetResource().GetFields()["kind"].GetStringValue()}
+			continue
+		}
+		converted[k] = convertedResource{object: u, kind: u.GetKind(), connectionDetails: v.GetConnectionDetails()}
+	}
+	return converted
+}
+
+// desiredResources returns the desired composed resources that could be
+// converted, and the desired composite resource, keyed by their desired
+// resource map key or reserved key.
+func desiredResources(rs map[string]*fnv1.Resource, dxr *sdkresource.Composite) map[string]conditionedObject {
+	desired := make(map[string]conditionedObject, len(rs)+1)
+	for k, r := range convertResources(rs) {
+		if r.err == nil {
+			desired[k] = r.object
+		}
+	}
+	desired[compositeResourceKey] = dxr.Resource
+	return desired
+}
+
+// filterResources returns the resources selected by the supplied selector.
+// Resources that could not be converted are kept so that the conversion error
+// is still surfaced by the matchers that select them.
+func filterResources(rs map[string]convertedResource, sel v1beta1.ResourceSelector) (map[string]convertedResource, error) {
+	var re *regexp.Regexp
+	if sel.Name != nil {
+		var err error
+		if re, err = regexp.Compile(*sel.Name); err != nil {
+			return nil, errors.Wrap(err, "cannot compile resource selector name regex")
+		}
+	}
+
+	filtered := make(map[string]convertedResource, len(rs))
+	for k, v := range rs {
+		if re != nil && !re.MatchString(k) {
+			continue
+		}
+		if v.err == nil && (!hasAll(v.object.GetLabels(), sel.MatchLabels) || !hasAll(v.object.GetAnnotations(), sel.MatchAnnotations)) {
+			continue
+		}
+		filtered[k] = v
+	}
+	return filtered, nil
+}
+
+// hasAll reports whether have contains all of the key value pairs in want.
+func hasAll(have, want map[string]string) bool {
+	for k, v := range want {
+		if hv, ok := have[k]; !ok || hv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// matchResult is the result of matching a matcher against its resources.
+type matchResult struct {
+	// Whether the matcher matched.
+	matched bool
+	// The regular expression groups captured while matching.
+	groups map[string]string
+	// The resources selected by the matcher.
+	resources map[string]conditionedObject
+	// The resources that matched, in the order they were evaluated.
+	matchedResources []matchedResource
+	// The percentage of the selected resources that matched, when the matcher
+	// counts matches.
+	matchesPercent *float64
+	// The number of selected resources, when the matcher compares it.
+	resourceCount *int
+}
+
+// matchedResource is a resource that matched, or that was selected but did not
+// match. Both are available to message templates.
+type matchedResource struct {
+	// Key of the resource in the observed resource map, or a reserved key.
+	Key string
+	// Name of the resource.
+	Name string
+	// Kind of the resource.
+	Kind string
+	// Condition is the first condition of the resource that matched.
+	Condition xpv1.Condition
+	// Conditions of the resource that matched.
+	Conditions []xpv1.Condition
+	// ConditionCount is the number of conditions the resource has, including
+	// those that were not matched.
+	ConditionCount int
+	// Groups captured while matching the resource.
+	Groups map[string]string
+	// Error converting the resource to an object, if it could not be
+	// converted.
+	Error string
+
+	// The resource, from which conditions can be copied.
+	object conditionedObject
+	// The observed composite resource, whose conditions can be compared to
+	// those of the resource.
+	composite conditionedObject
+}
+
+// Object returns the unstructured content of the resource, so templates can
+// reference arbitrary fields of it. It returns nil if the resource could not be
+// converted to an object.
+func (mr matchedResource) Object() map[string]any {
+	if mr.object == nil {
+		return nil
+	}
+	return mr.object.UnstructuredContent()
+}
+
+// CompositeConditions returns the conditions of the composite resource keyed
+// by type, so templates can show them next to the condition of the resource
+// they were compared with.
+func (mr matchedResource) CompositeConditions() map[string]xpv1.Condition {
+	if mr.composite == nil {
+		return nil
+	}
+	ts := conditionTypes(mr.composite)
+	cs := make(map[string]xpv1.Condition, len(ts))
+	for _, t := range ts {
+		cs[t] = mr.composite.GetCondition(xpv1.ConditionType(t))
+	}
+	return cs
+}
+
+// resourceValue returns the value of the supplied matched resource available
+// to templates under the Resource key. It holds the fields of the resource
+// object, e.g. spec, alongside the fields of the matched resource, e.g. Key and
+// Condition, which take precedence. Null fields of the object are omitted, so
+// that navigating into them renders empty text rather than failing the
+// template.
+func resourceValue(mr matchedResource) map[string]any {
+	v := map[string]any{}
+	if obj := mr.Object(); obj != nil {
+		v = withoutNulls(obj)
+	}
+	v["Key"] = mr.Key
+	v["Name"] = mr.Name
+	v["Kind"] = mr.Kind
+	v["Condition"] = mr.Condition
+	v["Conditions"] = mr.Conditions
+	v["ConditionCount"] = mr.ConditionCount
+	v["Groups"] = mr.Groups
+	v["Error"] = mr.Error
+	v["CompositeConditions"] = mr.CompositeConditions()
+	return v
+}
+
+// withoutNulls returns a copy of the supplied object without its null fields.
+func withoutNulls(obj map[string]any) map[string]any {
+	out := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if v == nil {
+			continue
+		}
+		out[k] = withoutNullsValue(v)
+	}
+	return out
+}
+
+func withoutNullsValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		return withoutNulls(t)
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = withoutNullsValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// matchOptions configure how a matcher is evaluated.
+type matchOptions struct {
+	// How to handle capture groups of the same name with different values.
+	onGroupConflict v1beta1.GroupConflictPolicy
+	// The maximum length in bytes of a condition message matched against a
+	// regular expression. Zero or less disables the limit.
+	maxMatchedMessageLength int
+	// The desired version of each resource, keyed by its observed resource
+	// map key or reserved key.
+	desired map[string]conditionedObject
+	// The groups captured from the key of each selected resource by the
+	// matcher's resource names.
+	nameGroups map[string]map[string]string
+	// The current time, to compare lastTransitionTimes to.
+	now time.Time
+	// The observed composite resource, to compare conditions to.
+	composite conditionedObject
+}
+
+func matchResources(ctx context.Context, mc v1beta1.Matcher, observedMap, extraMap map[string]convertedResource, xr *sdkresource.Composite, opts matchOptions) (matchResult, error) {
+	if ptr.Deref(mc.ConversionFailed, false) {
+		return matchConversionFailed(mc, observedMap)
+	}
+
+	observed := observedMap
+	if mc.PresentIn != nil {
+		// Desired resources that are not observed yet can also be selected.
+		observedMap = withDesiredResources(observedMap, opts.desired)
+	}
+	rs, err := selectResources(ctx, mc, observedMap, extraMap, xr)
+	if err != nil {
+		return matchResult{}, err
+	}
+	if len(mc.MatchOwnerReferences) > 0 {
+		rs, err = filterOwned(rs, mc.MatchOwnerReferences)
+		if err != nil {
+			return matchResult{}, err
+		}
+	}
+	if len(mc.MatchLabels) > 0 || len(mc.MatchAnnotations) > 0 {
+		rs = filterMetadata(rs, mc.MatchLabels, mc.MatchAnnotations)
+	}
+	if mc.ConditionsPath != nil {
+		rs, err = withConditionsAt(rs, *mc.ConditionsPath)
+		if err != nil {
+			return matchResult{}, err
+		}
+	}
+
+	if ptr.Deref(mc.Absent, false) {
+		// Only the absence of resources is matched.
+		return matchResult{matched: len(rs) == 0, resources: rs}, nil
+	}
+
+	if ptr.Deref(mc.Type, v1beta1.AllResourcesMatchAllConditions) == v1beta1.CompareResourceCount {
+		// Fewer resources than expected may be selected, even none.
+		return compareResourceCount(mc.ResourceCount, rs)
+	}
+
+	if len(rs) == 0 {
+		// There are no resources to match against.
+		return matchResult{}, nil
+	}
+
+	opts.nameGroups, err = resourceNameGroups(mc, rs, opts.onGroupConflict)
+	if err != nil {
+		return matchResult{}, err
+	}
+
+	mt := ptr.Deref(mc.Type, v1beta1.AllResourcesMatchAllConditions)
+	counting := mc.MinMatches != nil || mc.MaxMatches != nil || mc.MinMatchesPercent != nil || mc.MaxMatchesPercent != nil
+	if counting && ptr.Deref(mc.MinMatches, 0) > ptr.Deref(mc.MaxMatches, math.MaxInt) {
+		return matchResult{}, errors.Errorf("minMatches %d cannot be greater than maxMatches %d", *mc.MinMatches, *mc.MaxMatches)
+	}
+	if counting && ptr.Deref(mc.MinMatchesPercent, 0) > ptr.Deref(mc.MaxMatchesPercent, 100) {
+		return matchResult{}, errors.Errorf("minMatchesPercent %d cannot be greater than maxMatchesPercent %d", *mc.MinMatchesPercent, *mc.MaxMatchesPercent)
+	}
+
+	cs := rs
+	if mc.ResourceDeleting != nil {
+		cs = filterDeleting(cs, *mc.ResourceDeleting)
+	}
+	if mc.ConnectionDetailsPublished != nil {
+		cs = filterPublished(cs, *mc.ConnectionDetailsPublished, func(k string) bool {
+			return hasConnectionDetails(k, observedMap, extraMap, xr)
+		})
+	}
+	if mc.NoConditions != nil {
+		cs = filterNoConditions(cs, *mc.NoConditions)
+	}
+	if mc.ConditionChangedFromDesired != nil {
+		cs = filterChangedFromDesired(cs, *mc.ConditionChangedFromDesired, opts.desired)
+	}
+	if mc.ConsistentConditions != nil {
+		cs, err = filterConsistent(cs, *mc.ConsistentConditions)
+		if err != nil {
+			return matchResult{}, err
+		}
+	}
+	if mc.PresentIn != nil {
+		cs, err = filterPresence(cs, *mc.PresentIn, observed, opts.desired)
+		if err != nil {
+			return matchResult{}, err
+		}
+	}
+	if mc.SpecNotYetObserved != nil {
+		cs = filterNotYetObserved(cs, *mc.SpecNotYetObserved)
+	}
+	if mt == v1beta1.CompareStatusCounts {
+		res, err := compareStatusCounts(mc.StatusCounts, cs)
+		res.resources = rs
+		return res, err
+	}
+
+	if filtersByState(mc) {
+		switch {
+		case counting:
+			// Only resources in the desired state are counted.
+		case mt == v1beta1.AnyResourceMatchesAnyCondition, mt == v1beta1.AnyResourceMatchesAllConditions:
+			// Only resources in the desired state may match.
+			if len(cs) == 0 {
+				return matchResult{resources: rs}, nil
+			}
+		default:
+			// Every resource must be in the desired state.
+			if len(cs) != len(rs) {
+				return matchResult{resources: rs}, nil
+			}
+		}
+		if len(mc.Conditions) == 0 {
+			// The resource state is the only thing to match against.
+			res := matchResult{matched: true, resources: rs}
+			for _, k := range sortedKeys(cs) {
+				res.matchedResources = append(res.matchedResources, newMatchedResource(k, cs[k]))
+			}
+			if counting {
+				setMatchCount(&res, mc, len(rs))
+			}
+			return res, nil
+		}
+	}
+
+	if len(mc.Conditions) == 0 {
+		// There are no conditions to match against.
+		return matchResult{resources: rs}, nil
+	}
+
+	if counting {
+		res, err := countMatches(ctx, mc, mt, cs, len(rs), opts)
+		res.resources = rs
+		return res, err
+	}
+
+	var res matchResult
+	switch mt {
+	case v1beta1.AnyResourceMatchesAnyCondition:
+		res, err = anyResourceMatchesAnyCondition(ctx, mc.Conditions, cs, opts)
+	case v1beta1.AnyResourceMatchesAllConditions:
+		res, err = anyResourceMatchesAllConditions(ctx, mc.Conditions, cs, opts)
+	case v1beta1.AllResourcesMatchAnyCondition:
+		res, err = allResourcesMatchAnyConditions(ctx, mc.Conditions, rs, opts)
+	case v1beta1.ResourceMatchesAllConditions:
+		res, err = resourceMatchesAllConditions(ctx, mc.Conditions, rs, opts)
+	case v1beta1.AllResourcesMatchAllConditions:
+		fallthrough
+	default:
+		res, err = allResourcesMatchAllConditions(ctx, mc.Conditions, rs, opts)
+	}
+	res.resources = rs
+	return res, err
+}
+
+// countMatches matches when the number of resources that satisfy the
+// per-resource criteria of the match type is within the matcher's minMatches
+// and maxMatches, and their percentage of the selected resources is within
+// its minMatchesPercent and maxMatchesPercent.
+func countMatches(ctx context.Context, mc v1beta1.Matcher, mt v1beta1.MatchType, rs map[string]conditionedObject, selected int, opts matchOptions) (matchResult, error) {
+	var res matchResult
+	var err error
+	switch mt {
+	case v1beta1.AnyResourceMatchesAnyCondition, v1beta1.AllResourcesMatchAnyCondition:
+		res, err = anyResourceMatchesAnyCondition(ctx, mc.Conditions, rs, opts)
+	case v1beta1.AnyResourceMatchesAllConditions, v1beta1.AllResourcesMatchAllConditions, v1beta1.ResourceMatchesAllConditions:
+		fallthrough
+	default:
+		res, err = anyResourceMatchesAllConditions(ctx, mc.Conditions, rs, opts)
+	}
+	if err != nil {
+		return matchResult{}, err
+	}
+	setMatchCount(&res, mc, selected)
+	return res, nil
+}
+
+// setMatchCount sets whether the supplied result matched based on the number
+// of matched resources, and their percentage of the selected resources.
+func setMatchCount(res *matchResult, mc v1beta1.Matcher, selected int) {
+	n := len(res.matchedResources)
+	percent := 100 * float64(n) / float64(selected)
+	res.matchesPercent = &percent
+	// The percentage bounds are whole numbers, compared against the unrounded
+	// percentage.
+	res.matched = inRange(n, mc.MinMatches, mc.MaxMatches) &&
+		percent >= float64(ptr.Deref(mc.MinMatchesPercent, 0)) &&
+		percent <= float64(ptr.Deref(mc.MaxMatchesPercent, 100))
+}
+
+// compareStatusCounts matches when the number of resources whose condition has
+// the comparison's status compares to the number of resources whose condition
+// has the comparison's other status using the comparison's operator. The
+// resources with the first status are the matched resources.
+func compareStatusCounts(sc *v1beta1.StatusCountComparison, rs map[string]conditionedObject) (matchResult, error) {
+	if sc == nil {
+		return matchResult{}, errors.Errorf("statusCounts is required when type is %s", v1beta1.CompareStatusCounts)
+	}
+	status, err := parseStatus(sc.Status)
+	if err != nil {
+		return matchResult{}, err
+	}
+	other, err := parseStatus(sc.OtherStatus)
+	if err != nil {
+		return matchResult{}, err
+	}
+
+	res := matchResult{}
+	count, otherCount := 0, 0
+	for _, k := range sortedKeys(rs) {
+		c := rs[k].GetCondition(xpv1.ConditionType(sc.Type))
+		switch metav1.ConditionStatus(c.Status) {
+		case status:
+			count++
+			res.matchedResources = append(res.matchedResources, newMatchedResource(k, rs[k], v1beta1.ConditionMatcher{Type: sc.Type}))
+		case other:
+			otherCount++
+		}
+	}
+
+	res.matched, err = compareCounts(ptr.Deref(sc.Operator, v1beta1.ComparisonGreaterThan), count, otherCount)
+	if err != nil {
+		return matchResult{}, err
+	}
+	return res, nil
+}
+
+// compareResourceCount matches when the number of supplied resources compares
+// to the comparison's count using the comparison's operator. All of the
+// resources are matched resources.
+func compareResourceCount(rc *v1beta1.ResourceCountComparison, rs map[string]conditionedObject) (matchResult, error) {
+	if rc == nil {
+		return matchResult{}, errors.Errorf("resourceCount is required when type is %s", v1beta1.CompareResourceCount)
+	}
+
+	matched, err := compareCounts(ptr.Deref(rc.Operator, v1beta1.ComparisonEqual), len(rs), rc.Count)
+	if err != nil {
+		return matchResult{}, err
+	}
+	res := matchResult{matched: matched, resources: rs, resourceCount: ptr.To(len(rs))}
+	for _, k := range sortedKeys(rs) {
+		res.matchedResources = append(res.matchedResources, newMatchedResource(k, rs[k]))
+	}
+	return res, nil
+}
+
+// compareCounts compares count to other using the supplied operator.
+func compareCounts(op v1beta1.ComparisonOperator, count, other int) (bool, error) {
+	switch op {
+	case v1beta1.ComparisonGreaterThan:
+		return count > other, nil
+	case v1beta1.ComparisonGreaterThanOrEqual:
+		return count >= other, nil
+	case v1beta1.ComparisonLessThan:
+		return count < other, nil
+	case v1beta1.ComparisonLessThanOrEqual:
+		return count <= other, nil
+	case v1beta1.ComparisonEqual:
+		return count == other, nil
+	case v1beta1.ComparisonNotEqual:
+		return count != other, nil
+	default:
+		return false, errors.Errorf("invalid operator %s, must be one of [GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual, Equal, NotEqual]", op)
+	}
+}
+
+// inRange reports whether n is within the inclusive range. A nil bound is
+// unbounded.
+func inRange(n int, lower, upper *int) bool {
+	return n >= ptr.Deref(lower, 0) && n <= ptr.Deref(upper, math.MaxInt)
+}
+
+// filtersByState reports whether the matcher filters the selected resources by
+// their state, such as whether they are being deleted.
+func filtersByState(mc v1beta1.Matcher) bool {
+	return mc.ResourceDeleting != nil ||
+		mc.ConnectionDetailsPublished != nil ||
+		mc.NoConditions != nil ||
+		mc.ConditionChangedFromDesired != nil ||
+		mc.ConsistentConditions != nil ||
+		mc.PresentIn != nil ||
+		mc.SpecNotYetObserved != nil
+}
+
+// filterDeleting returns the resources whose deletion state matches deleting.
+// A resource is being deleted when it has a deletion timestamp.
+func filterDeleting(rs map[string]conditionedObject, deleting bool) map[string]conditionedObject {
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		if (r.GetDeletionTimestamp() != nil) == deleting {
+			out[k] = r
+		}
+	}
+	return out
+}
+
+// filterNotYetObserved returns the resources for which whether their latest
+// spec was not yet observed matches notObserved.
+func filterNotYetObserved(rs map[string]conditionedObject, notObserved bool) map[string]conditionedObject {
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		if specNotYetObserved(r) == notObserved {
+			out[k] = r
+		}
+	}
+	return out
+}
+
+// specNotYetObserved reports whether the generation of the resource is greater
+// than its observed generation. It is false if the resource does not report an
+// observed generation.
+func specNotYetObserved(r conditionedObject) bool {
+	observed, ok := nestedNumber(r.UnstructuredContent(), "status", "observedGeneration")
+	if !ok {
+		return false
+	}
+	generation, _ := nestedNumber(r.UnstructuredContent(), "metadata", "generation")
+	return generation > observed
+}
+
+// nestedNumber returns the integer at the supplied field path. Resources
+// converted from the request hold numbers as float64 rather than int64, so
+// unstructured.NestedInt64 cannot be used.
+func nestedNumber(obj map[string]any, fields ...string) (int64, bool) {
+	v, ok, err := unstructured.NestedFieldNoCopy(obj, fields...)
+	if err != nil || !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// filterMetadata returns the resources with all of the supplied labels and
+// annotations.
+func filterMetadata(rs map[string]conditionedObject, labels, annotations map[string]string) map[string]conditionedObject {
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		if hasAll(r.GetLabels(), labels) && hasAll(r.GetAnnotations(), annotations) {
+			out[k] = r
+		}
+	}
+	return out
+}
+
+// filterOwned returns the resources with an owner reference matched by any of
+// the supplied matchers.
+func filterOwned(rs map[string]conditionedObject, orms []v1beta1.OwnerReferenceMatcher) (map[string]conditionedObject, error) {
+	type compiled struct {
+		apiVersion, kind, name *regexp.Regexp
+		controller             *bool
+	}
+	cs := make([]compiled, len(orms))
+	for i, orm := range orms {
+		var err error
+		c := compiled{controller: orm.Controller}
+		if c.apiVersion, err = compileOptional(orm.APIVersion); err != nil {
+			return nil, errors.Wrapf(err, "cannot compile owner reference apiVersion regex, matchOwnerReferencesIndex: %d", i)
+		}
+		if c.kind, err = compileOptional(orm.Kind); err != nil {
+			return nil, errors.Wrapf(err, "cannot compile owner reference kind regex, matchOwnerReferencesIndex: %d", i)
+		}
+		if c.name, err = compileOptional(orm.Name); err != nil {
+			return nil, errors.Wrapf(err, "cannot compile owner reference name regex, matchOwnerReferencesIndex: %d", i)
+		}
+		cs[i] = c
+	}
+
+	matches := func(re *regexp.Regexp, s string) bool {
+		return re == nil || re.MatchString(s)
+	}
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		for _, ref := range r.GetOwnerReferences() {
+			owned := slices.ContainsFunc(cs, func(c compiled) bool {
+				return matches(c.apiVersion, ref.APIVersion) &&
+					matches(c.kind, ref.Kind) &&
+					matches(c.name, ref.Name) &&
+					(c.controller == nil || ptr.Deref(ref.Controller, false) == *c.controller)
+			})
+			if owned {
+				out[k] = r
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// compileOptional compiles the supplied regular expression, if any.
+func compileOptional(expr *string) (*regexp.Regexp, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	return regexp.Compile(*expr)
+}
+
+// filterPublished returns the resources whose connection details publish state
+// matches published.
+func filterPublished(rs map[string]conditionedObject, published bool, hasDetails func(k string) bool) map[string]conditionedObject {
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		if hasDetails(k) == published {
+			out[k] = r
+		}
+	}
+	return out
+}
+
+// filterNoConditions returns the resources whose lack of status conditions
+// matches none.
+func filterNoConditions(rs map[string]conditionedObject, none bool) map[string]conditionedObject {
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		if (len(conditionTypes(r)) == 0) == none {
+			out[k] = r
+		}
+	}
+	return out
+}
+
+// filterChangedFromDesired returns the resources whose condition of the
+// supplied type has a different status than the same condition of their
+// desired version. Resources without a desired version, or whose desired
+// version does not have the condition, are never returned.
+func filterChangedFromDesired(rs map[string]conditionedObject, ct string, desired map[string]conditionedObject) map[string]conditionedObject {
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		d, ok := desired[k]
+		if !ok {
+			continue
+		}
+		dc, ok := getCondition(d, xpv1.ConditionType(ct))
+		if !ok {
+			continue
+		}
+		if r.GetCondition(xpv1.ConditionType(ct)).Status != dc.Status {
+			out[k] = r
+		}
+	}
+	return out
+}
+
+// filterConsistent returns the resources whose conditions of the supplied types
+// are all present and share the same status, which must be the supplied status
+// if any.
+func filterConsistent(rs map[string]conditionedObject, cc v1beta1.ConsistentConditions) (map[string]conditionedObject, error) {
+	if len(cc.Types) == 0 {
+		return nil, errors.New("consistentConditions must have at least one type")
+	}
+	var want metav1.ConditionStatus
+	if cc.Status != nil {
+		s, err := parseStatus(*cc.Status)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse consistentConditions status")
+		}
+		want = s
+	}
+
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		status := want
+		consistent := true
+		for _, t := range cc.Types {
+			c, ok := getCondition(r, xpv1.ConditionType(t))
+			if !ok {
+				consistent = false
+				break
+			}
+			if status == "" {
+				status = metav1.ConditionStatus(c.Status)
+			}
+			if metav1.ConditionStatus(c.Status) != status {
+				consistent = false
+				break
+			}
+		}
+		if consistent {
+			out[k] = r
+		}
+	}
+	return out, nil
+}
+
+// reservedKeys returns the sorted keys of the supplied resources that start
+// with the reserved key prefix.
+func reservedKeys(rs map[string]convertedResource) []string {
+	var keys []string
+	for _, k := range sortedKeys(rs) {
+		if strings.HasPrefix(k, reservedKeyPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// withDesiredResources returns the supplied observed resources, and the desired
+// composed resources that are not observed.
+func withDesiredResources(observedMap map[string]convertedResource, desired map[string]conditionedObject) map[string]convertedResource {
+	merged := make(map[string]convertedResource, len(observedMap)+len(desired))
+	for k, r := range observedMap {
+		merged[k] = r
+	}
+	for k, r := range desired {
+		if _, ok := merged[k]; ok || k == compositeResourceKey {
+			continue
+		}
+		merged[k] = convertedResource{object: r, kind: r.GetObjectKind().GroupVersionKind().Kind}
+	}
+	return merged
+}
+
+// filterPresence returns the resources whose presence in the observed and
+// desired resources matches presence. The composite resource is always
+// present in both, and extra resources are only ever observed.
+func filterPresence(rs map[string]conditionedObject, presence v1beta1.ResourcePresence, observedMap map[string]convertedResource, desired map[string]conditionedObject) (map[string]conditionedObject, error) {
+	switch presence {
+	case v1beta1.PresentInObservedOnly, v1beta1.PresentInDesiredOnly, v1beta1.PresentInBoth:
+	default:
+		return nil, errors.Errorf("invalid presentIn %s, must be one of [ObservedOnly, DesiredOnly, Both]", presence)
+	}
+
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		_, isObserved := observedMap[k]
+		_, isDesired := desired[k]
+		if k == compositeResourceKey {
+			isObserved = true
+		}
+		var p v1beta1.ResourcePresence
+		switch {
+		case isObserved && isDesired:
+			p = v1beta1.PresentInBoth
+		case isDesired:
+			p = v1beta1.PresentInDesiredOnly
+		default:
+			p = v1beta1.PresentInObservedOnly
+		}
+		if p == presence {
+			out[k] = r
+		}
+	}
+	return out, nil
+}
+
+// hasConnectionDetails reports whether connection details were observed for
+// the resource with the supplied key.
+func hasConnectionDetails(k string, observedMap, extraMap map[string]convertedResource, xr *sdkresource.Composite) bool {
+	if k == compositeResourceKey {
+		return len(xr.ConnectionDetails) > 0
+	}
+	if r, ok := observedMap[k]; ok {
+		return len(r.connectionDetails) > 0
+	}
+	return len(extraMap[k].connectionDetails) > 0
+}
+
+// matchConversionFailed matches the resources selected by the supplied
+// matcher's resource names that could not be converted to objects.
+func matchConversionFailed(mc v1beta1.Matcher, observedMap map[string]convertedResource) (matchResult, error) {
+	failed := map[string]string{}
+	for i, r := range mc.Resources {
+		re, err := compileResourceName(r)
+		if err != nil {
+			return matchResult{}, errors.Wrapf(err, "cannot compile resource key regex, resourcesIndex: %d", i)
+		}
+		for k, v := range observedMap {
+			if v.err != nil && !strings.HasPrefix(k, reservedKeyPrefix) && re.MatchString(resourceKey(r, k, v.kind)) {
+				failed[k] = v.err.Error()
+			}
+		}
+	}
+
+	res := matchResult{matched: len(failed) > 0}
+	for _, k := range sortedKeys(failed) {
+		res.matchedResources = append(res.matchedResources, matchedResource{Key: k, Error: failed[k]})
+	}
+	return res, nil
+}
+
+// withConditionsAt returns copies of the supplied resources whose conditions
+// are read from the supplied field path rather than status.conditions.
+func withConditionsAt(rs map[string]conditionedObject, path string) (map[string]conditionedObject, error) {
+	if _, err := fieldpath.Parse(path); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse conditionsPath %q", path)
+	}
+
+	out := make(map[string]conditionedObject, len(rs))
+	for k, r := range rs {
+		cs, err := fieldpath.Pave(r.UnstructuredContent()).GetValue(path)
+		if err != nil && !fieldpath.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "cannot get conditions of resource %q at %s", k, path)
+		}
+
+		// Only the maps along status.conditions are copied, the rest of
+		// the resource is shared with the original.
+		obj := maps.Clone(r.UnstructuredContent())
+		status, _ := obj["status"].(map[string]any)
+		status = maps.Clone(status)
+		if status == nil {
+			status = map[string]any{}
+		}
+		if err != nil {
+			delete(status, "conditions")
+		} else {
+			status["conditions"] = cs
+		}
+		obj["status"] = status
+		out[k] = &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: obj}}
+	}
+	return out, nil
+}
+
+// inGracePeriod reports whether any of the supplied resources was created less
+// than the grace period ago. Resources without a creation timestamp are never
+// within the grace period.
+func inGracePeriod(rs map[string]conditionedObject, gracePeriod time.Duration, now time.Time) bool {
+	for _, r := range rs {
+		created := r.GetCreationTimestamp()
+		if !created.IsZero() && now.Sub(created.Time) < gracePeriod {
+			return true
+		}
+	}
+	return false
+}
+
+// selectResources returns the resources selected by the matcher, keyed by their
+// observed resource map key or reserved key.
+func selectResources(ctx context.Context, mc v1beta1.Matcher, observedMap, extraMap map[string]convertedResource, xr *sdkresource.Composite) (map[string]conditionedObject, error) {
+	log := ctx.Value(logKey).(logging.Logger)
+
+	compositeOnly := ptr.Deref(mc.CompositeOnly, false)
+	extraOnly := ptr.Deref(mc.ExtraResourcesOnly, false)
+	switch {
+	case compositeOnly && extraOnly:
+		return nil, errors.New("compositeOnly and extraResourcesOnly cannot both be set")
+	case compositeOnly:
+		// Only the composite resource should be matched against.
+		return map[string]conditionedObject{compositeResourceKey: xr.Resource}, nil
+	case extraOnly:
+		// Only the extra resources should be matched against.
+		return selectExtraResources(ctx, mc.ExtraResources, extraMap)
+	}
+
+	rs := map[string]conditionedObject{}
+	for i, r := range mc.Resources {
+		re, err := compileResourceName(r)
+		if err != nil {
+			log.Info("cannot compile resource key regex", "resourcesIndex", i, "error", err)
+			return nil, errors.Wrapf(err, "cannot compile resource key regex, resourcesIndex: %d", i)
+		}
+		selected := false
+		for k, v := range observedMap {
+			if strings.HasPrefix(k, reservedKeyPrefix) {
+				// Reserved keys are only selected by their include flags.
+				log.Debug("skipping resource with reserved key", "resourcesIndex", i, "resource", k)
+				continue
+			}
+			if re.MatchString(resourceKey(r, k, v.kind)) {
+				log.Debug("selected resource", "resourcesIndex", i, "resource", k)
+				if v.err != nil {
+					log.Info("cannot convert resource to object", "resourcesIndex", i, "observedMapKey", k, "error", v.err)
+					return nil, errors.Wrapf(v.err, "cannot convert resource to object, resourcesIndex: %d, observedMapKey: %s", i, k)
+				}
+				rs[k] = v.object
+				selected = true
+			}
+		}
+		if !selected {
+			// A misspelled resource name silently matches nothing, so make
+			// it obvious when debugging.
+			log.Debug(fmt.Sprintf("resource matcher %q selected 0 resources", r.Name), "resourcesIndex", i)
+		}
+	}
+
+	if ptr.Deref(mc.IncludeCompositeAsResource, false) {
+		// The user wants to match against conditions of the composite resource.
+		rs[compositeResourceKey] = xr.Resource
+	}
+
+	if ptr.Deref(mc.IncludeExtraResources, false) || len(mc.ExtraResources) > 0 {
+		// The user wants to match against conditions of the extra resources.
+		ers, err := selectExtraResources(ctx, mc.ExtraResources, extraMap)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range ers {
+			rs[k] = v
+		}
+	}
+
+	return rs, nil
+}
+
+// resourceKey returns the key of the composed resource with the supplied map
+// key and kind that the name of the supplied resource matcher is matched
+// against.
+func resourceKey(rm v1beta1.ResourceMatcher, k, kind string) string {
+	if ptr.Deref(rm.Key, v1beta1.ResourceKeyName) == v1beta1.ResourceKeyKindAndName {
+		return kind + "/" + k
+	}
+	return k
+}
+
+// compileResourceName compiles the name of the supplied resource matcher to a
+// regular expression according to its match mode.
+func compileResourceName(rm v1beta1.ResourceMatcher) (*regexp.Regexp, error) {
+	switch k := ptr.Deref(rm.Key, v1beta1.ResourceKeyName); k {
+	case v1beta1.ResourceKeyName, v1beta1.ResourceKeyKindAndName:
+	default:
+		return nil, errors.Errorf("invalid key %s, must be one of [Name, KindAndName]", k)
+	}
+	switch mm := ptr.Deref(rm.MatchMode, v1beta1.ResourceMatchRegex); mm {
+	case v1beta1.ResourceMatchRegex:
+		if ptr.Deref(rm.Anchored, false) {
+			return regexp.Compile(anchor(rm.Name))
+		}
+		return regexp.Compile(rm.Name)
+	case v1beta1.ResourceMatchGlob:
+		var b strings.Builder
+		b.WriteString("^")
+		for _, r := range rm.Name {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteString("$")
+		return regexp.Compile(b.String())
+	case v1beta1.ResourceMatchExact:
+		return regexp.Compile("^" + regexp.QuoteMeta(rm.Name) + "$")
+	default:
+		return nil, errors.Errorf("invalid match mode %s, must be one of [Regex, Glob, Exact]", mm)
+	}
+}
+
+// anchor anchors the supplied regular expression so that it must match the
+// whole string. The group is non-capturing, so positional capture groups are
+// not affected.
+func anchor(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
+// selectExtraResources returns the extra resources selected by the supplied
+// resource matchers, or all of the extra resources if there are none.
+func selectExtraResources(ctx context.Context, rms []v1beta1.ResourceMatcher, extraMap map[string]convertedResource) (map[string]conditionedObject, error) {
+	log := ctx.Value(logKey).(logging.Logger)
+
+	res := make([]*regexp.Regexp, len(rms))
+	for i, r := range rms {
+		re, err := compileResourceName(r)
+		if err != nil {
+			log.Info("cannot compile extra resource key regex", "extraResourcesIndex", i, "error", err)
+			return nil, errors.Wrapf(err, "cannot compile extra resource key regex, extraResourcesIndex: %d", i)
+		}
+		res[i] = re
+	}
+
+	rs := make(map[string]conditionedObject, len(extraMap))
+	for k, v := range extraMap {
+		if len(res) > 0 && !extraResourceSelected(res, k, v) {
+			continue
+		}
+		if v.err != nil {
+			log.Info("cannot convert extra resource to object", "extraResourceKey", k, "error", v.err)
+			return nil, errors.Wrapf(v.err, "cannot convert extra resource to object, extraResourceKey: %s", k)
+		}
+		rs[k] = v.object
+	}
+	return rs, nil
+}
+
+// extraResourceSelected reports whether any of the supplied regular
+// expressions match one of the names of the extra resource.
+func extraResourceSelected(res []*regexp.Regexp, key string, r convertedResource) bool {
+	for _, re := range res {
+		for _, name := range extraResourceNames(key, r) {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extraResourceNames returns the names an extra resource can be selected by.
+// These are the name it was requested under followed by either its index or
+// its metadata.name, e.g. buckets.0 and buckets.bucket-a. The metadata.name of
+// a namespaced extra resource is prefixed by its namespace, e.g.
+// buckets.team-a/bucket-a, as resources requested under the same name may
+// share a metadata.name in different namespaces.
+func extraResourceNames(key string, r convertedResource) []string {
+	indexed := strings.TrimPrefix(key, extraResourceKey+".")
+	names := []string{indexed}
+	if r.err != nil || r.object.GetName() == "" {
+		return names
+	}
+	into := indexed[:strings.LastIndex(indexed, ".")]
+	name := r.object.GetName()
+	if ns := r.object.GetNamespace(); ns != "" {
+		name = ns + "/" + name
+	}
+	return append(names, into+"."+name)
+}
+
+func anyResourceMatchesAnyCondition(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject, opts matchOptions) (matchResult, error) {
+	log := ctx.Value(logKey).(logging.Logger)
+	res := matchResult{}
+	for _, k := range sortedKeys(rm) {
+		r := rm[k]
+		for cmi, cm := range cms {
+			log := log.WithValues("resource", k, "conditionIndex", cmi)
+			ctx := context.WithValue(ctx, logKey, log)
+			m, cg, err := match(ctx, cm, r, opts)
+			if err != nil {
+				log.Info("cannot match resource", "error", err)
+				return matchResult{}, err
+			}
+			if !m {
+				continue
+			}
+			capturedGroups := nameGroups(opts, k)
+			if err := mergeGroups(capturedGroups, cg, opts.onGroupConflict); err != nil {
+				return matchResult{}, err
+			}
+			if !res.matched {
+				// The groups captured by the first match are used.
+				res.matched, res.groups = true, capturedGroups
+			}
+			mr := newMatchedResource(k, r, cm)
+			mr.Groups = capturedGroups
+			res.matchedResources = append(res.matchedResources, mr)
+			break
+		}
+	}
+
+	return res, nil
+}
+
+func anyResourceMatchesAllConditions(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject, opts matchOptions) (matchResult, error) {
+	log := ctx.Value(logKey).(logging.Logger)
+	res := matchResult{}
+	for _, k := range sortedKeys(rm) {
+		r := rm[k]
+		capturedGroups := nameGroups(opts, k)
+		matched := 0
+		for cmi, cm := range cms {
+			log := log.WithValues("resource", k, "conditionIndex", cmi)
+			ctx := context.WithValue(ctx, logKey, log)
+			m, cg, err := match(ctx, cm, r, opts)
+			if err != nil {
+				log.Info("cannot match resource", "error", err)
+				return matchResult{}, err
+			}
+			if !m {
+				break
+			}
+			matched++
+			if err := mergeGroups(capturedGroups, cg, opts.onGroupConflict); err != nil {
+				return matchResult{}, err
+			}
+		}
+		if matched != len(cms) {
+			continue
+		}
+		if !res.matched {
+			// The groups captured by the first matching resource are used.
+			res.matched, res.groups = true, capturedGroups
+		}
+		mr := newMatchedResource(k, r, cms...)
+		mr.Groups = capturedGroups
+		res.matchedResources = append(res.matchedResources, mr)
+	}
+
+	return res, nil
+}
+
+func allResourcesMatchAnyConditions(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject, opts matchOptions) (matchResult, error) {
+	log := ctx.Value(logKey).(logging.Logger)
+	res := matchResult{groups: map[string]string{}}
+	failed := false
+	for _, k := range sortedKeys(rm) {
+		r := rm[k]
+		capturedGroups := nameGroups(opts, k)
+		var matched []v1beta1.ConditionMatcher
+		for cmi, cm := range cms {
+			log := log.WithValues("resource", k, "conditionIndex", cmi)
+			ctx := context.WithValue(ctx, logKey, log)
+			m, cg, err := match(ctx, cm, r, opts)
+			if err != nil {
+				log.Info("cannot match resource", "error", err)
+				return matchResult{}, err
+			}
+			if !m {
+				continue
+			}
+			matched = append(matched, cm)
+			if err := mergeGroups(capturedGroups, cg, opts.onGroupConflict); err != nil {
+				return matchResult{}, err
+			}
+		}
+		if len(matched) == 0 {
+			// Keep evaluating, so that every resource that did not match
+			// is known.
+			failed = true
+			continue
+		}
+		if err := mergeGroups(res.groups, capturedGroups, opts.onGroupConflict); err != nil {
+			return matchResult{}, err
+		}
+		mr := newMatchedResource(k, r, matched...)
+		mr.Groups = capturedGroups
+		res.matchedResources = append(res.matchedResources, mr)
+	}
+
+	res.matched = !failed
+	return res, nil
+}
+
+func allResourcesMatchAllConditions(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject, opts matchOptions) (matchResult, error) {
+	log := ctx.Value(logKey).(logging.Logger)
+	res := matchResult{groups: map[string]string{}}
+	failed := false
+resources:
+	for _, k := range sortedKeys(rm) {
+		r := rm[k]
+		capturedGroups := nameGroups(opts, k)
+		for cmi, cm := range cms {
+			log := log.WithValues("resource", k, "conditionIndex", cmi)
+			ctx := context.WithValue(ctx, logKey, log)
+			m, cg, err := match(ctx, cm, r, opts)
+			if err != nil {
+				log.Info("cannot match resource", "error", err)
+				return matchResult{}, err
+			}
+			if !m {
+				// Keep evaluating, so that every resource that did not
+				// match is known.
+				failed = true
+				continue resources
+			}
+			if err := mergeGroups(capturedGroups, cg, opts.onGroupConflict); err != nil {
+				return matchResult{}, err
+			}
+		}
+		if err := mergeGroups(res.groups, capturedGroups, opts.onGroupConflict); err != nil {
+			return matchResult{}, err
+		}
+		mr := newMatchedResource(k, r, cms...)
+		mr.Groups = capturedGroups
+		res.matchedResources = append(res.matchedResources, mr)
+	}
+
+	res.matched = !failed
+	return res, nil
+}
+
+// resourceMatchesAllConditions reports whether the single selected resource
+// matches all conditions.
+func resourceMatchesAllConditions(ctx context.Context, cms []v1beta1.ConditionMatcher, rm map[string]conditionedObject, opts matchOptions) (matchResult, error) {
+	if len(rm) > 1 {
+		return matchResult{}, errors.Errorf("%s requires a single resource, but %d resources were selected", v1beta1.ResourceMatchesAllConditions, len(rm))
+	}
+	return allResourcesMatchAllConditions(ctx, cms, rm, opts)
+}
+
+// newMatchedResource returns the matched resource exposed to templates for the
+// supplied resource and the condition matchers it matched.
+func newMatchedResource(key string, r conditionedObject, cms ...v1beta1.ConditionMatcher) matchedResource {
+	mr := matchedResource{
+		Key:            key,
+		Name:           r.GetName(),
+		Kind:           r.GetObjectKind().GroupVersionKind().Kind,
+		ConditionCount: len(conditionTypes(r)),
+		object:         r,
+	}
+	for _, cm := range cms {
+		mr.Conditions = append(mr.Conditions, r.GetCondition(xpv1.ConditionType(cm.Type)))
+	}
+	if len(mr.Conditions) > 0 {
+		mr.Condition = mr.Conditions[0]
+	}
+	return mr
+}
+
+// resourceKeys returns the keys of the supplied matched resources.
+func resourceKeys(mrs []matchedResource) []string {
+	keys := make([]string, 0, len(mrs))
+	for _, mr := range mrs {
+		keys = append(keys, mr.Key)
+	}
+	return keys
+}
+
+// withComposite sets the composite resource of each supplied matched resource.
+func withComposite(mrs []matchedResource, xr conditionedObject) []matchedResource {
+	for i := range mrs {
+		mrs[i].composite = xr
+	}
+	return mrs
+}
+
+// unmatched returns the resources selected by the matcher that did not match,
+// sorted by key. Their Conditions are the current conditions of the types of
+// the supplied condition matchers.
+func unmatched(res matchResult, cms []v1beta1.ConditionMatcher) []matchedResource {
+	matched := make(map[string]bool, len(res.matchedResources))
+	for _, mr := range res.matchedResources {
+		matched[mr.Key] = true
+	}
+	var out []matchedResource
+	for _, k := range sortedKeys(res.resources) {
+		if !matched[k] {
+			out = append(out, newMatchedResource(k, res.resources[k], cms...))
+		}
+	}
+	return out
+}
+
+// nameGroups returns a copy of the groups captured from the supplied resource
+// key by the matcher's resource names.
+func nameGroups(opts matchOptions, k string) map[string]string {
+	groups := maps.Clone(opts.nameGroups[k])
+	if groups == nil {
+		groups = map[string]string{}
+	}
+	return groups
+}
+
+// resourceNameGroups returns the named groups captured from the keys of the
+// supplied resources by the regular expression resource names of the supplied
+// matcher, keyed by resource key. Positional groups are not captured, so that
+// they cannot be confused with groups captured from messages.
+func resourceNameGroups(mc v1beta1.Matcher, rs map[string]conditionedObject, policy v1beta1.GroupConflictPolicy) (map[string]map[string]string, error) {
+	if ptr.Deref(mc.CompositeOnly, false) || ptr.Deref(mc.ExtraResourcesOnly, false) {
+		return nil, nil
+	}
+	groups := map[string]map[string]string{}
+	for i, r := range mc.Resources {
+		re, err := compileResourceName(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot compile resource key regex, resourcesIndex: %d", i)
+		}
+		if !slices.ContainsFunc(re.SubexpNames(), func(n string) bool { return n != "" }) {
+			continue
+		}
+		for _, k := range sortedKeys(rs) {
+			if strings.HasPrefix(k, reservedKeyPrefix) {
+				continue
+			}
+			matches := re.FindStringSubmatch(resourceKey(r, k, rs[k].GetObjectKind().GroupVersionKind().Kind))
+			if matches == nil {
+				continue
+			}
+			captured := map[string]string{}
+			for j, name := range re.SubexpNames() {
+				if j > 0 && name != "" {
+					captured[name] = matches[j]
+				}
+			}
+			if groups[k] == nil {
+				groups[k] = map[string]string{}
+			}
+			if err := mergeGroups(groups[k], captured, policy); err != nil {
+				return nil, errors.Wrapf(err, "cannot capture groups from resource key %s, resourcesIndex: %d", k, i)
+			}
+		}
+	}
+	return groups, nil
+}
+
+// mergeGroups copies the src capture groups into dst. A capture group that
+// already exists in dst with a different value is handled according to the
+// supplied conflict policy.
+func mergeGroups(dst, src map[string]string, policy v1beta1.GroupConflictPolicy) error {
+	for k, v := range src {
+		existing, ok := dst[k]
+		if !ok || existing == v {
+			dst[k] = v
+			continue
+		}
+		switch policy {
+		case v1beta1.GroupConflictKeep:
+			continue
+		case v1beta1.GroupConflictError:
+			return errors.Errorf("capture group %s has conflicting values %q and %q", k, existing, v)
+		case v1beta1.GroupConflictOverwrite:
+			fallthrough
+		default:
+			dst[k] = v
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the supplied map in sorted order, so that
+// resources are always evaluated in the same order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func match(ctx context.Context, cm v1beta1.ConditionMatcher, co conditionedObject, opts matchOptions) (bool, map[string]string, error) {
+	log := ctx.Value(logKey).(logging.Logger)
+	cmGroups := map[string]string{}
+
+	if cm.Exists != nil {
+		if _, ok := getCondition(co, xpv1.ConditionType(cm.Type)); ok != *cm.Exists {
+			log.Debug(fmt.Sprintf("condition exists \"%t\" did not match \"%t\"", ok, *cm.Exists))
+			return false, nil, nil
+		}
+	}
+
+	c := co.GetCondition(xpv1.ConditionType(cm.Type))
+	switch {
+	case cm.Reason == nil:
+		// Any reason matches.
+	case ptr.Deref(cm.ReasonRegex, false):
+		re, err := regexp.Compile(*cm.Reason)
+		if err != nil {
+			return false, nil, errors.Wrap(err, "cannot compile reason regex")
+		}
+		matches := re.FindStringSubmatch(string(c.Reason))
+		if len(matches) == 0 {
+			log.Debug(fmt.Sprintf("condition reason \"%s\" did not match \"%s\"", c.Reason, *cm.Reason))
+			return false, nil, nil
+		}
+		addCaptureGroups(cmGroups, re, matches)
+	case *cm.Reason != string(c.Reason):
+		log.Debug(fmt.Sprintf("condition reason \"%s\" did not match \"%s\"", c.Reason, *cm.Reason))
+		return false, nil, nil
+	}
+
+	if cm.Status != nil {
+		status, err := parseStatus(*cm.Status)
+		if err != nil {
+			return false, nil, err
+		}
+		if status != metav1.ConditionStatus(c.Status) {
+			log.Debug(fmt.Sprintf("condition status \"%s\" did not match \"%s\"", c.Status, status))
+			return false, nil, nil
+		}
+	}
+
+	if cm.EmptyMessage != nil && (c.Message == "") != *cm.EmptyMessage {
+		log.Debug(fmt.Sprintf("condition message \"%s\" did not match empty message \"%t\"", c.Message, *cm.EmptyMessage))
+		return false, nil, nil
+	}
+
+	if len(cm.MessageJSON) > 0 {
+		if opts.maxMatchedMessageLength > 0 && len(c.Message) > opts.maxMatchedMessageLength {
+			log.Info("condition message is too long to parse as JSON", "messageLength", len(c.Message), "maxMatchedMessageLength", opts.maxMatchedMessageLength)
+			return false, nil, nil
+		}
+		groups, ok, err := captureJSON(c.Message, cm.MessageJSON)
+		if err != nil {
+			return false, nil, err
+		}
+		if !ok {
+			log.Debug(fmt.Sprintf("condition message \"%s\" did not match JSON fields %v", c.Message, cm.MessageJSON))
+			return false, nil, nil
+		}
+		for k, v := range groups {
+			cmGroups[k] = v
+		}
+	}
+
+	if cm.TransitionTime != nil {
+		ok, err := compareTransitionTime(co, xpv1.ConditionType(cm.Type), *cm.TransitionTime)
+		if err != nil {
+			return false, nil, err
+		}
+		if !ok {
+			log.Debug(fmt.Sprintf("condition lastTransitionTime did not match \"%s %s\"", cm.TransitionTime.Operator, cm.TransitionTime.Type))
+			return false, nil, nil
+		}
+	}
+
+	if cm.MaxAge != nil {
+		tc, ok := getCondition(co, xpv1.ConditionType(cm.Type))
+		if !ok || tc.LastTransitionTime.IsZero() {
+			log.Debug("condition has no lastTransitionTime to compare to maxAge")
+			return false, nil, nil
+		}
+		if age := opts.now.Sub(tc.LastTransitionTime.Time); age > cm.MaxAge.Duration {
+			log.Debug(fmt.Sprintf("condition transitioned %s ago, more than maxAge \"%s\"", age, cm.MaxAge.Duration))
+			return false, nil, nil
+		}
+	}
+
+	if cm.CompareWithComposite != nil {
+		ok, err := compareWithComposite(c, xpv1.ConditionType(cm.Type), *cm.CompareWithComposite, opts.composite)
+		if err != nil {
+			return false, nil, err
+		}
+		if !ok {
+			log.Debug(fmt.Sprintf("condition %s did not match the composite resource's condition", cm.CompareWithComposite.Field))
+			return false, nil, nil
+		}
+	}
+
+	if cm.Message == nil && len(cm.MessageAnyOf) == 0 {
+		log.Debug("condition matched")
+		return true, cmGroups, nil
+	}
+
+	msg := c.Message
+	if opts.maxMatchedMessageLength > 0 && len(msg) > opts.maxMatchedMessageLength {
+		log.Info("condition message is too long, matching a truncated message", "messageLength", len(msg), "maxMatchedMessageLength", opts.maxMatchedMessageLength)
+		msg = truncateString(msg, opts.maxMatchedMessageLength)
+	}
+	anchored := ptr.Deref(cm.MessageAnchored, false)
+
+	// Match the message and build up a map of template arguments.
+	if cm.Message != nil {
+		ok, err := matchMessage(cmGroups, *cm.Message, anchored, msg)
+		if err != nil {
+			return false, nil, errors.Wrap(err, "cannot compile message regex")
+		}
+		if !ok {
+			log.Debug(fmt.Sprintf("condition message \"%s\" did not match \"%s\"", c.Message, *cm.Message))
+			return false, nil, nil
+		}
+	}
+
+	if len(cm.MessageAnyOf) > 0 {
+		matched := false
+		for i, pattern := range cm.MessageAnyOf {
+			ok, err := matchMessage(cmGroups, pattern, anchored, msg)
+			if err != nil {
+				return false, nil, errors.Wrapf(err, "cannot compile message regex, messageAnyOfIndex: %d", i)
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			log.Debug(fmt.Sprintf("condition message \"%s\" did not match any of %q", c.Message, cm.MessageAnyOf))
+			return false, nil, nil
+		}
+	}
+
+	log.Debug(fmt.Sprintf("condition matched - total captured groups: %v", cmGroups))
+
+	return true, cmGroups, nil
+}
+
+// matchMessage matches the supplied message against the supplied regular
+// expression, and adds its capture groups to the supplied groups if it
+// matched.
+func matchMessage(groups map[string]string, pattern string, anchored bool, msg string) (bool, error) {
+	if anchored {
+		pattern = anchor(pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	matches := re.FindStringSubmatch(msg)
+	if len(matches) == 0 {
+		return false, nil
+	}
+	addCaptureGroups(groups, re, matches)
+	return true, nil
+}
+
+// captureJSON parses the supplied message as a JSON object and returns the
+// values at the supplied field paths, keyed by name. It returns false if the
+// message is not a JSON object or has no value at any of the paths.
+func captureJSON(msg string, paths map[string]string) (map[string]string, bool, error) {
+	for _, name := range sortedKeys(paths) {
+		if _, err := fieldpath.Parse(paths[name]); err != nil {
+			return nil, false, errors.Wrapf(err, "cannot parse messageJSON field path for %s", name)
+		}
+	}
+
+	obj := map[string]any{}
+	if err := json.Unmarshal([]byte(msg), &obj); err != nil {
+		return nil, false, nil
+	}
+
+	p := fieldpath.Pave(obj)
+	groups := make(map[string]string, len(paths))
+	for name, path := range paths {
+		v, err := p.GetValue(path)
+		if err != nil {
+			return nil, false, nil
+		}
+		if s, ok := v.(string); ok {
+			groups[name] = s
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "cannot capture messageJSON field %s", name)
+		}
+		groups[name] = string(b)
+	}
+	return groups, true, nil
+}
+
+// compareTransitionTime reports whether the lastTransitionTime of the condition
+// of type ct compares to that of the other condition as the supplied
+// comparison requires. It is false if either condition is absent.
+func compareTransitionTime(co conditionedObject, ct xpv1.ConditionType, tc v1beta1.TransitionTimeComparison) (bool, error) {
+	c, ok := getCondition(co, ct)
+	if !ok {
+		return false, nil
+	}
+	other, ok := getCondition(co, xpv1.ConditionType(tc.Type))
+	if !ok {
+		return false, nil
+	}
+	switch tc.Operator {
+	case v1beta1.TransitionedBefore:
+		return c.LastTransitionTime.Before(&other.LastTransitionTime), nil
+	case v1beta1.TransitionedAfter:
+		return other.LastTransitionTime.Before(&c.LastTransitionTime), nil
+	default:
+		return false, errors.Errorf("invalid transitionTime operator %s, must be one of [Before, After]", tc.Operator)
+	}
+}
+
+// compareWithComposite reports whether the supplied field of condition c, of
+// type ct, compares to that of the composite resource's condition as the
+// supplied comparison requires.
+func compareWithComposite(c xpv1.Condition, ct xpv1.ConditionType, cc v1beta1.CompositeConditionComparison, xr conditionedObject) (bool, error) {
+	if xr == nil {
+		return false, errors.New("cannot compare with the composite resource's condition: no composite resource")
+	}
+	other := xr.GetCondition(xpv1.ConditionType(ptr.Deref(cc.Type, string(ct))))
+
+	var a, b string
+	switch cc.Field {
+	case v1beta1.ConditionFieldStatus:
+		a, b = string(c.Status), string(other.Status)
+	case v1beta1.ConditionFieldReason:
+		a, b = string(c.Reason), string(other.Reason)
+	case v1beta1.ConditionFieldMessage:
+		a, b = c.Message, other.Message
+	default:
+		return false, errors.Errorf("invalid compareWithComposite field %s, must be one of [Status, Reason, Message]", cc.Field)
+	}
+
+	switch op := ptr.Deref(cc.Operator, v1beta1.ComparisonEqual); op {
+	case v1beta1.ComparisonEqual:
+		return a == b, nil
+	case v1beta1.ComparisonNotEqual:
+		return a != b, nil
+	default:
+		return false, errors.Errorf("invalid compareWithComposite operator %s, must be one of [Equal, NotEqual]", op)
+	}
+}
+
+// addCaptureGroups adds the groups captured by re to groups.
+func addCaptureGroups(groups map[string]string, re *regexp.Regexp, matches []string) {
+	for i := 1; i < len(matches); i++ {
+		name := re.SubexpNames()[i]
+		if name == "" {
+			// Unnamed groups are referenced by their position, e.g. {{ ._1 }}.
+			name = positionalGroupPrefix + strconv.Itoa(i)
+		}
+		groups[name] = matches[i]
+	}
+}
+
+// parseStatus returns the condition status for the supplied value. Common
+// aliases such as true, yes, ok, false, and no are accepted case-insensitively.
+func parseStatus(s metav1.ConditionStatus) (metav1.ConditionStatus, error) {
+	switch strings.ToLower(string(s)) {
+	case "true", "yes", "ok":
+		return metav1.ConditionTrue, nil
+	case "false", "no":
+		return metav1.ConditionFalse, nil
+	case "unknown":
+		return metav1.ConditionUnknown, nil
+	default:
+		return "", errors.Errorf("invalid status %q, must be one of [True, False, Unknown]", s)
+	}
+}
+
+// transformOptions configure how conditions and events are rendered.
+type transformOptions struct {
+	// The maximum length of a rendered message. Zero disables truncation.
+	maxMessageLength int
+	// Whether to validate condition types and reasons against the Kubernetes
+	// conventions.
+	validateConditionFormat bool
+	// The type of event to create for each matched condition status, if the
+	// event does not set a type. Nil if events without a type are Normal.
+	statusToSeverity map[string]v1beta1.EventType
+	// Prepended and appended to rendered condition messages.
+	messagePrefix string
+	messageSuffix string
+}
+
+// statusToSeverity returns the default mapping of condition status to event
+// type, overridden by the supplied mapping. It returns nil if no mapping is
+// supplied, so that events without a type stay Normal. The supplied statuses
+// are parsed like condition statuses, so that e.g. a YAML key False that was
+// unmarshalled as "false" still applies.
+func statusToSeverity(overrides map[string]v1beta1.EventType) (map[string]v1beta1.EventType, error) {
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+	m := map[string]v1beta1.EventType{
+		string(corev1.ConditionTrue):    v1beta1.EventTypeNormal,
+		string(corev1.ConditionFalse):   v1beta1.EventTypeWarning,
+		string(corev1.ConditionUnknown): v1beta1.EventTypeNormal,
+	}
+	for _, k := range sortedKeys(overrides) {
+		status, err := parseStatus(metav1.ConditionStatus(k))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse statusToSeverity")
+		}
+		m[string(status)] = overrides[k]
+	}
+	return m, nil
+}
+
+// severityForStatus returns the first event type other than Normal that the
+// status of a matched condition maps to, or nil if there is none.
+func severityForStatus(matched []matchedResource, m map[string]v1beta1.EventType) *string {
+	for _, mr := range matched {
+		if t, ok := m[string(mr.Condition.Status)]; ok && t != v1beta1.EventTypeNormal {
+			return ptr.To(string(t))
+		}
+	}
+	return nil
+}
+
+func transformCondition(cs v1beta1.SetCondition, templateValues map[string]any, matched []matchedResource, opts transformOptions) (*fnv1.Condition, error) {
+	c := &fnv1.Condition{
+		Type:   cs.Condition.Type,
 		Reason: cs.Condition.Reason,
 		Target: transformTarget(cs.Target),
 	}
 
-	switch cs.Condition.Status {
-	case metav1.ConditionTrue:
-		c.Status = fnv1.Status_STATUS_CONDITION_TRUE
-	case metav1.ConditionFalse:
-		c.Status = fnv1.Status_STATUS_CONDITION_FALSE
-	case metav1.ConditionUnknown:
-		fallthrough
-	default:
-		c.Status = fnv1.Status_STATUS_CONDITION_UNKNOWN
+	if opts.validateConditionFormat {
+		if len(c.Type) > maxConditionTypeLength || !validConditionType.MatchString(c.Type) {
+			return &fnv1.Condition{}, errors.Errorf("invalid type %q, must be at most %d characters and match %s", c.Type, maxConditionTypeLength, validConditionType)
+		}
+		if len(c.Reason) > maxConditionReasonLength || !validReason.MatchString(c.Reason) {
+			return &fnv1.Condition{}, errors.Errorf("invalid reason %q, must be at most %d characters and match %s", c.Reason, maxConditionReasonLength, validReason)
+		}
 	}
 
-	msg, err := templateMessage(cs.Condition.Message, templateValues)
+	status, err := parseStatus(cs.Condition.Status)
 	if err != nil {
 		return &fnv1.Condition{}, err
 	}
+	c.Status = conditionStatuses[corev1.ConditionStatus(status)]
+
+	var msg *string
+	if cs.AggregateMessages != nil {
+		msg, err = aggregateMessages(cs.Condition.Message, templateValues, matched, *cs.AggregateMessages)
+	} else {
+		msg, err = templateMessage(cs.Condition.Message, templateValues)
+	}
+	if err != nil {
+		return &fnv1.Condition{}, err
+	}
+	if msg != nil {
+		msg = ptr.To(wrapMessage(*msg, opts.messagePrefix, opts.messageSuffix, ptr.Deref(cs.Condition.MaxMessageLength, opts.maxMessageLength)))
+	}
 	c.Message = msg
 
 	return c, nil
 }
 
-func transformEvent(ec v1beta1.CreateEvent, templateValues map[string]string) (*fnv1.Result, error) {
+// preconditionsMet returns true if the conditions of the supplied composite
+// resource match all of the supplied preconditions.
+func preconditionsMet(log logging.Logger, cms []v1beta1.ConditionMatcher, xr conditionedObject, opts matchOptions) (bool, error) {
+	ctx := context.WithValue(context.Background(), logKey, log)
+	for pi, cm := range cms {
+		ok, _, err := match(ctx, cm, xr, opts)
+		if err != nil {
+			return false, errors.Wrapf(err, "preconditionIndex: %d", pi)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// setConditionType returns the type of the condition set by the supplied
+// SetCondition.
+func setConditionType(cs v1beta1.SetCondition) string {
+	if cs.CopyCondition != nil {
+		return ptr.Deref(cs.CopyCondition.As, cs.CopyCondition.Type)
+	}
+	return cs.Condition.Type
+}
+
+// copyCondition returns a copy of the condition of the first matched resource
+// that has a condition of the type to copy, or nil if none do.
+func copyCondition(cs v1beta1.SetCondition, matched []matchedResource, opts transformOptions) (*fnv1.Condition, error) {
+	t := setConditionType(cs)
+	if opts.validateConditionFormat && (len(t) > maxConditionTypeLength || !validConditionType.MatchString(t)) {
+		return nil, errors.Errorf("invalid type %q, must be at most %d characters and match %s", t, maxConditionTypeLength, validConditionType)
+	}
+
+	for _, mr := range matched {
+		if mr.object == nil {
+			// The resource could not be converted, so it has no conditions.
+			continue
+		}
+		src, ok := getCondition(mr.object, xpv1.ConditionType(cs.CopyCondition.Type))
+		if !ok {
+			continue
+		}
+		c := &fnv1.Condition{
+			Type:   t,
+			Status: conditionStatuses[src.Status],
+			Reason: string(src.Reason),
+			Target: transformTarget(cs.Target),
+		}
+		if src.Message != "" {
+			c.Message = ptr.To(truncateMessage(src.Message, opts.maxMessageLength))
+		}
+		return c, nil
+	}
+	return nil, nil
+}
+
+// preserveTransitionTime replaces the message of the supplied condition with
+// the message of the existing condition of the same type on the composite
+// resource when only the message differs. Crossplane updates the
+// lastTransitionTime of a condition whenever any of its fields change, so
+// keeping the existing message preserves the lastTransitionTime.
+func preserveTransitionTime(c *fnv1.Condition, xr conditionedObject) {
+	existing, ok := getCondition(xr, xpv1.ConditionType(c.Type))
+	if !ok {
+		return
+	}
+	if conditionStatuses[existing.Status] != c.Status || string(existing.Reason) != c.Reason {
+		// The condition transitioned.
+		return
+	}
+	c.Message = nil
+	if existing.Message != "" {
+		c.Message = ptr.To(existing.Message)
+	}
+}
+
+func transformEvent(ec v1beta1.CreateEvent, templateValues map[string]any, matched []matchedResource, opts transformOptions) (*fnv1.Result, error) {
 	e := &fnv1.Result{
-		Reason: ec.Event.Reason,
 		Target: transformTarget(ec.Target),
 	}
 
-	switch ptr.Deref(ec.Event.Type, v1beta1.EventTypeNormal) {
+	reason, err := templateMessage(ec.Event.Reason, templateValues)
+	if err != nil {
+		return &fnv1.Result{}, errors.Wrap(err, "cannot render reason")
+	}
+	if opts.validateConditionFormat && reason != nil && !validReason.MatchString(*reason) {
+		return &fnv1.Result{}, errors.Errorf("invalid reason %q, must match %s", *reason, validReason)
+	}
+	e.Reason = reason
+
+	t, err := templateMessage((*string)(ec.Event.Type), templateValues)
+	if err != nil {
+		return &fnv1.Result{}, errors.Wrap(err, "cannot render type")
+	}
+	if t == nil {
+		t = severityForStatus(matched, opts.statusToSeverity)
+	}
+	switch et := v1beta1.EventType(ptr.Deref(t, string(v1beta1.EventTypeNormal))); et {
 	case v1beta1.EventTypeNormal:
 		e.Severity = fnv1.Severity_SEVERITY_NORMAL
 	case v1beta1.EventTypeWarning:
 		e.Severity = fnv1.Severity_SEVERITY_WARNING
 	default:
-		return &fnv1.Result{}, errors.Errorf("invalid type %s, must be one of [Normal, Warning]", *ec.Event.Type)
+		return &fnv1.Result{}, errors.Errorf("invalid type %s, must be one of [Normal, Warning]", et)
 	}
 
 	msg, err := templateMessage(&ec.Event.Message, templateValues)
 	if err != nil {
 		return &fnv1.Result{}, err
 	}
-	e.Message = ptr.Deref(msg, "")
+	e.Message = truncateMessage(ptr.Deref(msg, ""), ptr.Deref(ec.Event.MaxMessageLength, opts.maxMessageLength))
 	return e, nil
 }
 
+// wrapMessage adds the supplied prefix and suffix to the message, truncating
+// the message so that the result is at most maxLength bytes. The prefix and
+// suffix are only truncated if they don't fit on their own.
+func wrapMessage(msg, prefix, suffix string, maxLength int) string {
+	n := maxLength - len(prefix) - len(suffix)
+	if maxLength <= 0 || len(msg) <= n {
+		return prefix + msg + suffix
+	}
+	if n <= len(ellipsis) {
+		return truncateMessage(prefix+msg+suffix, maxLength)
+	}
+	return prefix + truncateMessage(msg, n) + suffix
+}
+
+// truncateMessage truncates the message to at most maxLength bytes, including
+// an ellipsis that marks the message as truncated. Multi-byte characters are
+// never split. A maxLength of zero or less disables truncation.
+func truncateMessage(msg string, maxLength int) string {
+	if maxLength <= 0 || len(msg) <= maxLength {
+		return msg
+	}
+	if maxLength <= len(ellipsis) {
+		return ellipsis[:maxLength]
+	}
+	return truncateString(msg, maxLength-len(ellipsis)) + ellipsis
+}
+
+// truncateString truncates s to at most n bytes without splitting a multi-byte
+// character. s must be longer than n.
+func truncateString(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 func transformTarget(t *v1beta1.Target) *fnv1.Target {
 	target := ptr.Deref(t, v1beta1.TargetComposite)
 	if target == v1beta1.TargetCompositeAndClaim {
@@ -421,15 +2908,26 @@ func transformTarget(t *v1beta1.Target) *fnv1.Target {
 	return fnv1.Target_TARGET_COMPOSITE.Enum()
 }
 
-func templateMessage(msg *string, values map[string]string) (*string, error) {
-	if msg == nil || len(values) == 0 {
+// templateMessage renders the supplied message template with the supplied
+// values. The template is rendered even if there are no values, so that
+// references to values that were not captured don't leak template syntax into
+// the rendered message. Such references render as empty text.
+func templateMessage(msg *string, values map[string]any) (*string, error) {
+	if msg == nil {
 		return msg, nil
 	}
 
-	t, err := template.New("").Parse(*msg)
+	t, err := template.New("").Funcs(template.FuncMap{emptyIfMissingFunc: emptyIfMissing}).Parse(*msg)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot parse template")
 	}
+	// text/template renders a missing value as <no value>, because the values
+	// are untyped. Render it as empty text instead.
+	for _, tt := range t.Templates() {
+		if tt.Tree != nil {
+			renderMissingAsEmpty(tt.Tree, tt.Tree.Root)
+		}
+	}
 	b := bytes.NewBuffer(nil)
 	if err := t.Execute(b, values); err != nil {
 		return nil, errors.Wrap(err, "cannot execute template")
@@ -437,7 +2935,160 @@ func templateMessage(msg *string, values map[string]string) (*string, error) {
 	return ptr.To(b.String()), nil
 }
 
+// renderMissingAsEmpty pipes the value of every action under the supplied node
+// that renders a value to emptyIfMissing.
+func renderMissingAsEmpty(tree *parse.Tree, n parse.Node) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			renderMissingAsEmpty(tree, c)
+		}
+	case *parse.ActionNode:
+		// An action that declares a variable doesn't render a value.
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		id := parse.NewIdentifier(emptyIfMissingFunc).SetTree(tree).SetPos(n.Pos)
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{id}})
+	case *parse.IfNode:
+		renderMissingAsEmpty(tree, n.List)
+		renderMissingAsEmpty(tree, n.ElseList)
+	case *parse.RangeNode:
+		renderMissingAsEmpty(tree, n.List)
+		renderMissingAsEmpty(tree, n.ElseList)
+	case *parse.WithNode:
+		renderMissingAsEmpty(tree, n.List)
+		renderMissingAsEmpty(tree, n.ElseList)
+	}
+}
+
+// emptyIfMissing returns empty text if the supplied value is missing, and the
+// value otherwise.
+func emptyIfMissing(v any) any {
+	if v == nil {
+		return ""
+	}
+	return v
+}
+
+// aggregateMessages renders the supplied message template once for each
+// matched resource, which is available under the Resource key, and joins the
+// rendered messages as configured by the supplied aggregation. The template is
+// rendered once if no resources matched.
+func aggregateMessages(msg *string, values map[string]any, matched []matchedResource, ma v1beta1.MessageAggregation) (*string, error) {
+	if msg == nil || len(matched) == 0 {
+		return templateMessage(msg, values)
+	}
+
+	// A capture group named Resource takes precedence over the resource the
+	// message is rendered for, like it does over the first matched resource.
+	_, captured := values[resourceTemplateKey]
+	if _, ok := values[resourceTemplateKey].(map[string]any); ok {
+		captured = false
+	}
+
+	msgs := make([]string, 0, len(matched))
+	seen := make(map[string]bool, len(matched))
+	for _, mr := range matched {
+		rv := maps.Clone(values)
+		if rv == nil {
+			rv = map[string]any{}
+		}
+		if !captured {
+			rv[resourceTemplateKey] = resourceValue(mr)
+		}
+		m, err := templateMessage(msg, rv)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot render message for resource %q", mr.Key)
+		}
+		if ptr.Deref(ma.Distinct, false) && seen[*m] {
+			continue
+		}
+		seen[*m] = true
+		msgs = append(msgs, *m)
+	}
+	return ptr.To(strings.Join(msgs, ptr.Deref(ma.Separator, defaultAggregateSeparator))), nil
+}
+
+// levelLogger adjusts the verbosity of a logger to the LogLevel requested by
+// the input.
+type levelLogger struct {
+	logging.Logger
+
+	level v1beta1.LogLevel
+}
+
+// Debug drops the message when the level is Info and promotes it to an info
+// message when the level is Debug.
+func (l levelLogger) Debug(msg string, keysAndValues ...any) {
+	switch l.level {
+	case v1beta1.LogLevelInfo:
+		return
+	case v1beta1.LogLevelDebug:
+		l.Logger.Info(msg, keysAndValues...)
+	default:
+		l.Logger.Debug(msg, keysAndValues...)
+	}
+}
+
+// WithValues returns a levelLogger that includes the supplied structured data.
+func (l levelLogger) WithValues(keysAndValues ...any) logging.Logger {
+	return levelLogger{Logger: l.Logger.WithValues(keysAndValues...), level: l.level}
+}
+
+// validReason matches a valid reason. It is the same pattern Kubernetes uses
+// to validate condition reasons.
+var validReason = regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$`)
+
+// validConditionType matches a valid condition type. It is the same pattern
+// Kubernetes uses to validate condition types, i.e. an optional DNS subdomain
+// prefix followed by a name such as Ready or example.org/Ready.
+var validConditionType = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])$`)
+
+// conditionStatuses maps the status of an observed condition to the status of
+// a condition in the response.
+var conditionStatuses = map[corev1.ConditionStatus]fnv1.Status{
+	corev1.ConditionTrue:    fnv1.Status_STATUS_CONDITION_TRUE,
+	corev1.ConditionFalse:   fnv1.Status_STATUS_CONDITION_FALSE,
+	corev1.ConditionUnknown: fnv1.Status_STATUS_CONDITION_UNKNOWN,
+}
+
+// getCondition returns the condition of the supplied type, and whether the
+// condition exists on the object. Unlike GetCondition it distinguishes an
+// absent condition from an Unknown one.
+func getCondition(co conditionedObject, ct xpv1.ConditionType) (xpv1.Condition, bool) {
+	cs := xpv1.ConditionedStatus{}
+	if err := fieldpath.Pave(co.UnstructuredContent()).GetValueInto("status", &cs); err != nil {
+		return xpv1.Condition{}, false
+	}
+	for _, c := range cs.Conditions {
+		if c.Type == ct {
+			return c, true
+		}
+	}
+	return xpv1.Condition{}, false
+}
+
+// conditionTypes returns the types of the conditions present on the supplied
+// object.
+func conditionTypes(co conditionedObject) []string {
+	cs := xpv1.ConditionedStatus{}
+	if err := fieldpath.Pave(co.UnstructuredContent()).GetValueInto("status", &cs); err != nil {
+		return nil
+	}
+	ts := make([]string, 0, len(cs.Conditions))
+	for _, c := range cs.Conditions {
+		ts = append(ts, string(c.Type))
+	}
+	return ts
+}
+
 type conditionedObject interface {
 	resource.Object
 	resource.Conditioned
+
+	UnstructuredContent() map[string]any
 }
@@ -2,22 +2,37 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
 	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
 	"github.com/crossplane/function-sdk-go/response"
+	"github.com/crossplane/function-status-transformer/input/v1beta1"
 )
 
 func TestRunFunction(t *testing.T) {
+	now := time.Date(2024, 8, 2, 16, 0, 0, 0, time.UTC)
 
 	type args struct {
 		ctx context.Context
@@ -1416,13 +1431,15 @@ func TestRunFunction(t *testing.T) {
 			},
 		},
 		"BadInput": {
-			reason: "The function should fail if the input cannot be parsed.",
+			reason: "The function should fail if the input has unknown fields.",
 			args: args{
 				ctx: context.TODO(),
 				req: &fnv1.RunFunctionRequest{
 					Meta: &fnv1.RequestMeta{Tag: "hello"},
 					Input: resource.MustStructJSON(`
 				{
+								"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+								"kind": "StatusTransformation",
 								"object": "not valid"
 				}
 				`),
@@ -2839,36 +2856,12904 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
+		"IncludeExtraResources": {
+			reason: "The function should merge extra resources with the other resources, or match them on their own when extraResourcesOnly is set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AllResourcesMatchAllConditions",
+          "includeExtraResources": true,
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "AllReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "type": "AllResourcesMatchAllConditions",
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "ObservedReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "type": "AllResourcesMatchAllConditions",
+          "extraResourcesOnly": true,
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "ExtraReady",
+            "status": "False",
+            "reason": "Unavailable"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+					ExtraResources: map[string]*fnv1.Resources{
+						"buckets": {
+							Items: []*fnv1.Resource{
+								{
+									Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Bucket",
+	"metadata": {
+		"name": "bucket-a"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "False",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "ObservedReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "ExtraReady",
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: "Unavailable",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"AllResourcesMatchAllConditionsOverObservedAndExtraResources": {
+			reason: "The function should match when all observed and extra resources match all conditions.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AllResourcesMatchAllConditions",
+          "includeExtraResources": true,
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "AllReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+					ExtraResources: map[string]*fnv1.Resources{
+						"buckets": {
+							Items: []*fnv1.Resource{
+								{
+									Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Bucket",
+	"metadata": {
+		"name": "bucket-a"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "AllReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"PreserveTransitionTime": {
+			reason: "The function should keep the existing message of a composite condition when only the message changed and preserveTransitionTime is set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "includeCompositeAsResource": true,
+          "conditions": [
+            {
+              "type": "Synced"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "preserveTransitionTime": true,
+          "condition": {
+            "type": "MessageChanged",
+            "status": "False",
+            "reason": "Failed",
+            "message": "new message"
+          }
+        },
+        {
+          "target": "Composite",
+          "preserveTransitionTime": true,
+          "condition": {
+            "type": "StatusChanged",
+            "status": "True",
+            "reason": "Available",
+            "message": "new message"
+          }
+        },
+        {
+          "target": "Composite",
+          "preserveTransitionTime": true,
+          "condition": {
+            "type": "NewCondition",
+            "status": "True",
+            "reason": "Available",
+            "message": "new message"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "NotPreserved",
+            "status": "False",
+            "reason": "Failed",
+            "message": "new message"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"lastTransitionTime": "2024-08-02T15:57:20Z",
+				"message": "old message",
+				"reason": "Failed",
+				"status": "False",
+				"type": "MessageChanged"
+			},
+			{
+				"lastTransitionTime": "2024-08-02T15:57:20Z",
+				"message": "old message",
+				"reason": "Failed",
+				"status": "False",
+				"type": "StatusChanged"
+			},
+			{
+				"lastTransitionTime": "2024-08-02T15:57:20Z",
+				"message": "old message",
+				"reason": "Failed",
+				"status": "False",
+				"type": "NotPreserved"
+			}
+		]
+	}
+}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "MessageChanged",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Failed",
+							Message: ptr.To("old message"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:    "StatusChanged",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "Available",
+							Message: ptr.To("new message"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:    "NewCondition",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "Available",
+							Message: ptr.To("new message"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:    "NotPreserved",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Failed",
+							Message: ptr.To("new message"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CompositeOnly": {
+			reason: "The function should only match against the composite resource when compositeOnly is set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "Something went wrong: (?P<Error>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Error }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "Something went wrong: some lower level error",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("some lower level error"),
+							Target:  fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CompositeOnlyAndExtraResourcesOnly": {
+			reason: "The function should fail to match when both compositeOnly and extraResourcesOnly are set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "extraResourcesOnly": true,
+          "conditions": [
+            {
+              "type": "Synced"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	}
+}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MatchFailure",
+							Message: ptr.To("cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: compositeOnly and extraResourcesOnly cannot both be set"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EnvironmentFromContext": {
+			reason: "The function should expose the environment from the function context to templates and enabled gating.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "enabled": "{{ eq .Env.name \"prod\" }}",
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "Something went wrong: (?P<Error>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "ProdSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Env.name }}: {{ .Error }}"
+          }
+        }
+      ]
+    },
+    {
+      "enabled": "{{ eq .Env.name \"dev\" }}",
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "DevSynced",
+            "status": "False",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Context: resource.MustStructJSON(`
+{
+	"apiextensions.crossplane.io/environment": {
+		"name": "prod"
+	}
+}`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "Something went wrong: some lower level error",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Context: resource.MustStructJSON(`
+{
+	"apiextensions.crossplane.io/environment": {
+		"name": "prod"
+	}
+}`),
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "ProdSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("prod: some lower level error"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EnvironmentFromCustomContextKey": {
+			reason: "The function should read the environment from the configured function context key.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "environmentContextKey": "example.org/environment",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "Failed in {{ .Env.region }}."
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Context: resource.MustStructJSON(`
+{
+	"example.org/environment": {
+		"region": "us-east-1"
+	}
+}`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Context: resource.MustStructJSON(`
+{
+	"example.org/environment": {
+		"region": "us-east-1"
+	}
+}`),
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("Failed in us-east-1."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"GracePeriod": {
+			reason: "The function should not fire a hook while a selected resource is within the grace period.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "gracePeriod": "5m",
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "new-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "NewSynced",
+            "status": "False",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    },
+    {
+      "gracePeriod": "5m",
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "old-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "OldSynced",
+            "status": "False",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"new-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "new-name",
+		"creationTimestamp": "2024-08-02T15:58:00Z"
+	},
+	"status": {
+		"conditions": [
+			{
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"old-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "old-name",
+		"creationTimestamp": "2024-08-02T15:00:00Z"
+	},
+	"status": {
+		"conditions": [
+			{
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "OldSynced",
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: "ReconcileError",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"GroupConflictOverwrite": {
+			reason: "By default, a capture group captured later should overwrite one of the same name captured earlier.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        },
+        {
+          "resources": [
+            {
+              "name": "cache"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Code }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "database"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: DB01",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"cache": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "cache"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: CA02",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("CA02"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"GroupConflictKeep": {
+			reason: "With the Keep policy, a capture group captured earlier should be kept.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "onGroupConflict": "Keep",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        },
+        {
+          "resources": [
+            {
+              "name": "cache"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Code }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "database"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: DB01",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"cache": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "cache"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: CA02",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("DB01"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"GroupConflictError": {
+			reason: "With the Error policy, conflicting capture groups should fail to match.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "onGroupConflict": "Error",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        },
+        {
+          "resources": [
+            {
+              "name": "cache"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Code }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "database"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: DB01",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"cache": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "cache"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: CA02",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MatchFailure",
+							Message: ptr.To("cannot merge capture groups, statusConditionHookIndex: 0, matchConditionIndex: 1: capture group Code has conflicting values \"DB01\" and \"CA02\""),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"GroupConflictErrorLenient": {
+			reason: "With the Error policy and strictMatching false, conflicting capture groups should only skip the hook.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "onGroupConflict": "Error",
+  "strictMatching": false,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        },
+        {
+          "resources": [
+            {
+              "name": "cache"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error code: (?P<Code>\\w+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Code }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "database"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: DB01",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"cache": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "cache"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error code: CA02",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EmitErrorEvents": {
+			reason: "The function should create a Warning event for each failure when emitErrorEvents is set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "emitErrorEvents": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "message": "(?!"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  "cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: cannot compile message regex: error parsing regexp: invalid or unsupported Perl syntax: `(?!`",
+							Reason:   ptr.To("MatchFailure"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MatchFailure",
+							Message: ptr.To("cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: cannot compile message regex: error parsing regexp: invalid or unsupported Perl syntax: `(?!`"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"StatusAliases": {
+			reason: "The function should accept status aliases and fail to set a condition with an invalid status.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "false"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "LowercaseTrue",
+            "status": "true",
+            "reason": "Example"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "UppercaseNo",
+            "status": "NO",
+            "reason": "Example"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Ok",
+            "status": "ok",
+            "reason": "Example"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Invalid",
+            "status": "maybe",
+            "reason": "Example"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "LowercaseTrue",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Example",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "UppercaseNo",
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: "Example",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "Ok",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Example",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "SetConditionFailure",
+							Message: ptr.To("cannot set condition, statusConditionHookIndex: 0, setConditionIndex: 3: invalid status \"maybe\", must be one of [True, False, Unknown]"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"TruncateLongMessages": {
+			reason: "The function should truncate rendered messages that exceed the maximum length.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "maxMessageLength": 20,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "Something went wrong: (?P<Error>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "GlobalLimit",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Error }}"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "ConditionLimit",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Error }}",
+            "maxMessageLength": 10
+          }
+        }
+      ],
+      "createEvents": [
+        {
+          "target": "Composite",
+          "event": {
+            "type": "Warning",
+            "reason": "ReconcileError",
+            "message": "{{ .Error }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "Something went wrong: the quick brown fox jumps over the lazy dog",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  "the quick brown f...",
+							Reason:   ptr.To("ReconcileError"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "GlobalLimit",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("the quick brown f..."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:    "ConditionLimit",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("the qui..."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceDeleting": {
+			reason: "The function should match when any selected resource is being deleted.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "resourceDeleting": true,
+          "resources": [
+            {
+              "name": "example-mr-.*"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Deleting",
+            "status": "True",
+            "reason": "ResourceDeleting"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr-a": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name-a",
+		"deletionTimestamp": "2024-08-02T15:00:00Z"
+	}
+}`),
+							},
+							"example-mr-b": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name-b"
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Deleting",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "ResourceDeleting",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceNotDeleting": {
+			reason: "The function should not match a resource without a deletion timestamp when resourceDeleting is true, even if its conditions match.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resourceDeleting": true,
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Deleting",
+            "status": "True",
+            "reason": "ResourceDeleting"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"PositionalCaptureGroups": {
+			reason: "The function should make unnamed capture groups available by position alongside named capture groups.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error (?P<Code>\\d+): (\\w+) in (\\w+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Code }} {{ ._2 }} {{ ._3 }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error 403: forbidden in useast1",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("403 forbidden useast1"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"RespectDesiredConditions": {
+			reason: "The function should not override conditions already present on the desired composite resource when respectDesiredConditions is set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "respectDesiredConditions": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "True",
+            "reason": "Available"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "XR",
+	"status": {
+		"conditions": [
+			{
+				"reason": "SetByEarlierFunction",
+				"status": "False",
+				"type": "CustomSynced"
+			}
+		]
+	}
+}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "XR",
+	"status": {
+		"conditions": [
+			{
+				"reason": "SetByEarlierFunction",
+				"status": "False",
+				"type": "CustomSynced"
+			}
+		]
+	}
+}`),
+						},
+					},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "CustomReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ReasonRegex": {
+			reason: "The function should match the reason as a regular expression and capture groups from it when reasonRegex is set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "reason": "^Create.*",
+              "reasonRegex": true
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CreateFailed",
+            "status": "True",
+            "reason": "CreateFailed"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "reason": "^Reconcile(?P<Kind>.+)$",
+              "reasonRegex": true
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileFailed",
+            "message": "reconcile failed: {{ .Kind }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "Something went wrong",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileFailed",
+							Message: ptr.To("reconcile failed: Error"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"DisableSuccessCondition": {
+			reason: "The function should not set the success condition when emitSuccessCondition is false.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "emitSuccessCondition": false,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "CustomSynced",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"DisableSuccessConditionWithFailure": {
+			reason: "The function should still set the failure condition when emitSuccessCondition is false.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "emitSuccessCondition": false,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "message": "a bad regex (?!)"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MatchFailure",
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+							Message: ptr.To("cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: cannot compile message regex: error parsing regexp: invalid or unsupported Perl syntax: `(?!`"),
+						},
+					},
+				},
+			},
+		},
+		"ResourceMatchesAllConditions": {
+			reason: "The function should match a single resource that matches all conditions.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "ResourceMatchesAllConditions",
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            },
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			},
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+							"other-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "other-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "False",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "DatabaseReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceMatchesAllConditionsMultipleResources": {
+			reason: "The function should return a match failure when ResourceMatchesAllConditions selects more than one resource.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "ResourceMatchesAllConditions",
+          "resources": [
+            {
+              "name": ".*-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	}
+}`),
+							},
+							"other-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "other-name"
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MatchFailure",
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+							Message: ptr.To("cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: ResourceMatchesAllConditions requires a single resource, but 2 resources were selected"),
+						},
+					},
+				},
+			},
+		},
+		"TemplatedEventReason": {
+			reason: "The function should render the event reason as a template.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error (?P<Code>\\d+): (?P<Error>.+)"
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "target": "Composite",
+          "event": {
+            "type": "Warning",
+            "reason": "FailedWithCode{{ .Code }}",
+            "message": "{{ .Error }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error 403: forbidden",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  "forbidden",
+							Reason:   ptr.To("FailedWithCode403"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"InvalidTemplatedEventReason": {
+			reason: "The function should return a failure when the rendered event reason is invalid and validateConditionFormat is true.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "validateConditionFormat": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "error (?P<Code>\\d+): (?P<Error>.+)"
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "target": "Composite",
+          "event": {
+            "type": "Warning",
+            "reason": "{{ .Error }}",
+            "message": "{{ .Error }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "error 403: access forbidden",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "SetConditionFailure",
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+							Message: ptr.To("cannot create event, statusConditionHookIndex: 0, createEventIndex: 0: invalid reason \"access forbidden\", must match ^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$"),
+						},
+					},
+				},
+			},
+		},
+		"ResourceSelector": {
+			reason: "The function should never match observed resources that are not selected by the resource selector.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "resourceSelector": {
+    "name": ".*-mr",
+    "matchLabels": {
+      "monitored": "true"
+    }
+  },
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "resources": [
+            {
+              "name": ".*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": ".*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"monitored-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "monitored-name",
+		"labels": {
+			"monitored": "true"
+		}
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"unmonitored-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "unmonitored-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"monitored-other": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "monitored-other-name",
+		"labels": {
+			"monitored": "true"
+		}
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "CustomSynced",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"SelectExtraResources": {
+			reason: "The function should select extra resources by their index or their name.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "extraResourcesOnly": true,
+          "extraResources": [
+            {
+              "name": "^buckets\\.1$"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "SelectedByIndex",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "extraResourcesOnly": true,
+          "extraResources": [
+            {
+              "name": "^buckets\\.bucket-b$"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "SelectedByName",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "extraResourcesOnly": true,
+          "extraResources": [
+            {
+              "name": "^buckets\\.bucket-a$"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "NotReadyBucket",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					ExtraResources: map[string]*fnv1.Resources{
+						"buckets": {
+							Items: []*fnv1.Resource{
+								{
+									Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Bucket",
+	"metadata": {
+		"name": "bucket-a"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "False",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+								},
+								{
+									Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Bucket",
+	"metadata": {
+		"name": "bucket-b"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "SelectedByIndex",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "SelectedByName",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"LenientMatching": {
+			reason: "The function should treat matcher failures as non-matches when strictMatching is false.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "strictMatching": false,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "a bad regex (?!)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomReady",
+            "status": "False",
+            "reason": "InternalError",
+            "message": "a matcher failed, this should not be set"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "Something went wrong: (?P<Error>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomReady",
+            "status": "False",
+            "reason": "InternalError",
+            "message": "{{ .Error }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "Something went wrong: some lower level error",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "InternalError",
+							Message: ptr.To("some lower level error"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MatchedResourcesTemplate": {
+			reason: "The function should make all matched resources available to message templates.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "resources": [
+            {
+              "name": ".*-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ range .MatchedResources }}{{ .Kind }} {{ .Name }}: {{ .Condition.Message }}. {{ end }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"a-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Database",
+	"metadata": {
+		"name": "db-a"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "quota exceeded",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"b-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Database",
+	"metadata": {
+		"name": "db-b"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "access denied",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+							"c-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Database",
+	"metadata": {
+		"name": "db-c"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "",
+				"status": "True",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("Database db-a: quota exceeded. Database db-b: access denied. "),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ReadinessRollupAllReady": {
+			reason: "The readiness rollup should set a True condition when all resources are synced and ready.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "readinessRollup": {
+    "target": "CompositeAndClaim"
+  }
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"bucket": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "bucket"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			},
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "database"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			},
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Ready",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ReadinessRollupPartiallyReady": {
+			reason: "The readiness rollup should set a False condition listing the unready resources when some resources are not synced or ready.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "readinessRollup": {
+    "target": "CompositeAndClaim"
+  }
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"bucket": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "bucket"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "False",
+				"type": "Synced"
+			},
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "database"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			},
+			{
+				"status": "False",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+							"network": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "network"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			},
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Ready",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("unready resources: bucket, database"),
+							Target:  fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ConnectionDetailsPublished": {
+			reason: "The function should match resources based on whether they have published connection details.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "connectionDetailsPublished": true,
+          "resources": [
+            {
+              "name": "published-mr"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "PublishedMatched",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "connectionDetailsPublished": true,
+          "resources": [
+            {
+              "name": "pending-mr"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "UnpublishedMatchedAsPublished",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "connectionDetailsPublished": false,
+          "resources": [
+            {
+              "name": "pending-mr"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "UnpublishedMatched",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"published-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "published-mr"
+	}
+}`),
+								ConnectionDetails: map[string][]byte{
+									"password": []byte("secret"),
+								},
+							},
+							"pending-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "pending-mr"
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "PublishedMatched",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "UnpublishedMatched",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"SameConditionTypeDifferentTargets": {
+			reason: "The function should set a condition of the same type once for each target.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "Something went wrong: (?P<Error>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "FailedToCreate",
+            "message": "The database could not be created."
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "FailedToCreate",
+            "message": "The database could not be created: {{ .Error }}"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "FailedToCreate",
+            "message": "This condition should not be set."
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"message": "Something went wrong: some lower level error",
+				"reason": "ReconcileError",
+				"status": "False",
+				"type": "Synced"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "FailedToCreate",
+							Message: ptr.To("The database could not be created."),
+							Target:  fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "FailedToCreate",
+							Message: ptr.To("The database could not be created: some lower level error"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"DuplicateConditionPerTarget": {
+			reason: "The function should skip duplicate conditions per target, while force still overrides a condition of the same target.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Ready",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Ready",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "composite"
+          }
+        },
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Ready",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "composite and claim"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Ready",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "skipped"
+          }
+        },
+        {
+          "target": "Composite",
+          "force": true,
+          "condition": {
+            "type": "Ready",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "forced"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Ready",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("composite"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:    "Ready",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("composite and claim"),
+							Target:  fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:    "Ready",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("forced"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"NoConditions": {
+			reason: "The function should match resources based on whether they have no status conditions at all.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "noConditions": true,
+          "resources": [
+            {
+              "name": "empty-mr"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "EmptyMatched",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "noConditions": true,
+          "resources": [
+            {
+              "name": "conditioned-mr"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "ConditionedMatchedAsEmpty",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "noConditions": false,
+          "resources": [
+            {
+              "name": "conditioned-mr"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "ConditionedMatched",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"empty-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "empty-mr"
+	},
+	"status": {
+		"conditions": []
+	}
+}`),
+							},
+							"conditioned-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "conditioned-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "True",
+				"reason": "ReconcileSuccess",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "EmptyMatched",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "ConditionedMatched",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"SortConditions": {
+			reason: "The function should sort the conditions it sets by type and target, with the success condition last, when sortConditions is enabled.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "sortConditions": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Zeta",
+            "status": "True",
+            "reason": "Available"
+          }
+        },
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Alpha",
+            "status": "True",
+            "reason": "Available"
+          }
+        },
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Zeta",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Alpha",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "Zeta",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "Zeta",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"HookTTL": {
+			reason: "The function should use the shortest TTL suggested by a matched hook.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "ttl": "5m",
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Slow",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "ttl": "15s",
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Fast",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "ttl": "1s",
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": true
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Unmatched",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(15 * time.Second)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Slow",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "Fast",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ConditionChangedFromDesired": {
+			reason: "The function should match resources whose condition status differs between their desired and observed versions.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "conditionChangedFromDesired": "Ready",
+          "resources": [
+            {
+              "name": ".*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Degraded",
+            "status": "True",
+            "reason": "ResourceNoLongerReady",
+            "message": "{{ range .MatchedResources }}{{ .Name }} {{ end }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"changed-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "changed-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Unavailable",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"unchanged-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "unchanged-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Unavailable",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"undesired-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "undesired-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Unavailable",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"changed-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "changed-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"unchanged-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "unchanged-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Unavailable",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"undesired-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "undesired-mr"
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"changed-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "changed-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"unchanged-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "unchanged-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Unavailable",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"undesired-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "undesired-mr"
+	}
+}`),
+							},
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Degraded",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "ResourceNoLongerReady",
+							Message: ptr.To("changed-mr "),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"NamedMatcherCaptureGroups": {
+			reason: "The function should make the capture groups of named matchers available under their name, as well as directly.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "name": "db",
+          "resources": [
+            {
+              "name": "db-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "(?P<Error>.+)"
+            }
+          ]
+        },
+        {
+          "name": "cache",
+          "resources": [
+            {
+              "name": "cache-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "(?P<Error>.+)"
+            }
+          ]
+        },
+        {
+          "resources": [
+            {
+              "name": "queue-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "(?P<QueueError>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "BackendsReady",
+            "status": "False",
+            "reason": "FailedToCreate",
+            "message": "db: {{ .db.Error }}, cache: {{ .cache.Error }}, last: {{ .Error }}, queue: {{ .QueueError }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"db-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "db-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "database quota exceeded",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"cache-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "cache-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "cache node unavailable",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+							"queue-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "queue-mr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "queue not found",
+				"lastTransitionTime": "2024-01-01T00:00:00Z"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "BackendsReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "FailedToCreate",
+							Message: ptr.To("db: database quota exceeded, cache: cache node unavailable, last: cache node unavailable, queue: queue not found"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"HooksFromContext": {
+			reason: "The function should append the hooks stored in the function context to the hooks of the input.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "hooksContextKey": "example.org/shared-hooks",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "FromInput",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Context: resource.MustStructJSON(`
+{
+	"example.org/shared-hooks": [
+		{
+			"matchers": [
+				{
+					"compositeOnly": true,
+					"conditions": [
+						{
+							"type": "Synced",
+							"exists": false
+						}
+					]
+				}
+			],
+			"setConditions": [
+				{
+					"target": "Composite",
+					"condition": {
+						"type": "FromInput",
+						"status": "False",
+						"reason": "Overridden"
+					}
+				},
+				{
+					"target": "Composite",
+					"condition": {
+						"type": "FromContext",
+						"status": "True",
+						"reason": "Available"
+					}
+				}
+			]
+		}
+	]
+}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Context: resource.MustStructJSON(`
+{
+	"example.org/shared-hooks": [
+		{
+			"matchers": [
+				{
+					"compositeOnly": true,
+					"conditions": [
+						{
+							"type": "Synced",
+							"exists": false
+						}
+					]
+				}
+			],
+			"setConditions": [
+				{
+					"target": "Composite",
+					"condition": {
+						"type": "FromInput",
+						"status": "False",
+						"reason": "Overridden"
+					}
+				},
+				{
+					"target": "Composite",
+					"condition": {
+						"type": "FromContext",
+						"status": "True",
+						"reason": "Available"
+					}
+				}
+			]
+		}
+	]
+}`),
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "FromInput",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "FromContext",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"HooksFromContextNotAList": {
+			reason: "The function should return a failure condition when the hooks in the function context are not a list.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "hooksContextKey": "example.org/shared-hooks"
+}
+`),
+					Context: resource.MustStructJSON(`
+{
+	"example.org/shared-hooks": "not-a-list"
+}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Context: resource.MustStructJSON(`
+{
+	"example.org/shared-hooks": "not-a-list"
+}`),
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "InputFailure",
+							Message: ptr.To("cannot get hooks from function context key example.org/shared-hooks: not a list"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"IndependentForcePerTarget": {
+			reason: "The function should apply force independently to each target of a condition type.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        },
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "FailedToCreate"
+          }
+        },
+        {
+          "target": "Composite",
+          "force": true,
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "FailedToCreate"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "DatabaseReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "DatabaseReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "DatabaseReady",
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: "FailedToCreate",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CompositeResourceInEventMessage": {
+			reason: "The function should make the composite resource available to event message templates.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "target": "Composite",
+          "event": {
+            "reason": "Provisioned",
+            "message": "{{ .XRName }} in {{ .XRNamespace }} owned by {{ .XR.metadata.labels.team }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "example.org/v1",
+	"kind": "XDatabase",
+	"metadata": {
+		"name": "my-db-x7k2p",
+		"labels": {
+			"team": "platform",
+			"crossplane.io/claim-namespace": "team-a"
+		}
+	}
+}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Reason:   ptr.To("Provisioned"),
+							Message:  "my-db-x7k2p in team-a owned by platform",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MatchesPercentTemplate": {
+			reason: "The function should make the percentage of resources that matched available to templates.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "maxMatchesPercent": 79,
+          "resources": [
+            {
+              "name": "mr-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Degraded",
+            "status": "True",
+            "reason": "NotEnoughReady",
+            "message": "{{ printf \"%.0f\" .MatchesPercent }}% of resources are ready."
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"mr-0": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+							"mr-1": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+							"mr-2": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Unavailable"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Degraded",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "NotEnoughReady",
+							Message: ptr.To("67% of resources are ready."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"WildcardExcludesReservedKeys": {
+			reason: "The function should only select observed resources with a wildcard, never the composite resource, extra resources, or resources with reserved keys, and warn about resources with reserved keys.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": ".*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "AllReady",
+            "status": "True",
+            "reason": "Available",
+            "message": "{{ range .MatchedResources }}{{ .Key }} {{ end }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "example.org/v1",
+	"kind": "XR",
+	"metadata": {
+		"name": "example-xr"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Creating"
+			}
+		]
+	}
+}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+							"function-status-transformer.reserved-keys.composite-resource": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Creating"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+					ExtraResources: map[string]*fnv1.Resources{
+						"buckets": {
+							Items: []*fnv1.Resource{
+								{
+									Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Bucket",
+	"metadata": {
+		"name": "bucket-a"
+	},
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Creating"
+			}
+		]
+	}
+}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  `ignoring observed resources whose keys start with the reserved prefix "function-status-transformer.reserved-keys.": function-status-transformer.reserved-keys.composite-resource`,
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "AllReady",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "Available",
+							Message: ptr.To("example-mr "),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"WhenAllHooksEvaluatedNotDegraded": {
+			reason: "The function should set the catch-all Healthy condition when no hook set Degraded.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Degraded",
+            "status": "True",
+            "reason": "ResourceUnavailable"
+          }
+        }
+      ]
+    }
+  ],
+  "whenAllHooksEvaluated": [
+    {
+      "whenNotSet": ["Degraded"],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Healthy",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "whenSet": ["Degraded"],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Healthy",
+            "status": "False",
+            "reason": "Degraded"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Example"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Healthy",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"WhenAllHooksEvaluatedDegraded": {
+			reason: "The function should not set the catch-all Healthy condition when a hook set Degraded.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Degraded",
+            "status": "True",
+            "reason": "ResourceUnavailable"
+          }
+        }
+      ]
+    }
+  ],
+  "whenAllHooksEvaluated": [
+    {
+      "whenNotSet": ["Degraded"],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Healthy",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "whenSet": ["Degraded"],
+      "setConditions": [
+        {
+          "target": "CompositeAndClaim",
+          "condition": {
+            "type": "Healthy",
+            "status": "False",
+            "reason": "Degraded"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Example"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Degraded",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "ResourceUnavailable",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "Healthy",
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: "Degraded",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"PresentInDesiredOnly": {
+			reason: "The function should select desired resources that are not observed yet when presentIn is set.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "presentIn": "DesiredOnly",
+          "resources": [
+            {
+              "name": ".*"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Creating",
+            "status": "True",
+            "reason": "ResourcesPending",
+            "message": "{{ range .MatchedResources }}{{ .Key }} {{ end }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"existing-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object"
+}`),
+							},
+						},
+					},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"existing-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object"
+}`),
+							},
+							"created-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object"
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Desired: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"existing-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object"
+}`),
+							},
+							"created-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object"
+}`),
+							},
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Creating",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "ResourcesPending",
+							Message: ptr.To("created-mr "),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"UnmatchedResourcesTemplate": {
+			reason: "The function should make the selected resources that did not match available to templates.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "maxMatchesPercent": 99,
+          "resources": [
+            {
+              "name": "mr-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Degraded",
+            "status": "True",
+            "reason": "NotReady",
+            "message": "{{ range .UnmatchedResources }}{{ .Key }}: {{ .Condition.Reason }}. {{ end }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"mr-0": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Creating"
+			}
+		]
+	}
+}`),
+							},
+							"mr-1": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+							"mr-2": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Unavailable"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Degraded",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "NotReady",
+							Message: ptr.To("mr-0: Creating. mr-2: Unavailable. "),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CopyConditionRenamed": {
+			reason: "The function should copy a condition from a matched resource verbatim, renaming its type.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "cloudsql"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "copyCondition": {
+            "type": "Synced",
+            "as": "DatabaseSynced"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"cloudsql": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "sql.gcp.upbound.io/v1beta1",
+	"kind": "DatabaseInstance",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "create failed: googleapi: Error 400: Invalid request: Invalid Tier (db-custom-0-0)."
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("create failed: googleapi: Error 400: Invalid request: Invalid Tier (db-custom-0-0)."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CopyConditionNotPresent": {
+			reason: "The function should not set a condition if no matched resource has the condition to copy.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "cloudsql"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "copyCondition": {
+            "type": "Ready"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"cloudsql": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "sql.gcp.upbound.io/v1beta1",
+	"kind": "DatabaseInstance",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"AggregateMessages": {
+			reason: "The function should render the message once for each matched resource and join the results.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "resources": [
+            {
+              "name": "bucket-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "^cannot observe bucket: (?P<error>.+)$"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "aggregateMessages": {},
+          "condition": {
+            "type": "BucketsSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "{{ .Resource.Key }}: {{ .Resource.Groups.error }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"bucket-a": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "s3.aws.upbound.io/v1beta1",
+	"kind": "Bucket",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "cannot observe bucket: access denied"
+			}
+		]
+	}
+}`),
+							},
+							"bucket-b": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "s3.aws.upbound.io/v1beta1",
+	"kind": "Bucket",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "cannot observe bucket: not found"
+			}
+		]
+	}
+}`),
+							},
+							"bucket-c": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "s3.aws.upbound.io/v1beta1",
+	"kind": "Bucket",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "True",
+				"reason": "ReconcileSuccess"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "BucketsSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("bucket-a: access denied; bucket-b: not found"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"AggregateDistinctReasons": {
+			reason: "The function should list each distinct reason of the matched resources once.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "resources": [
+            {
+              "name": "bucket-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "aggregateMessages": {
+            "separator": ", ",
+            "distinct": true
+          },
+          "condition": {
+            "type": "Degraded",
+            "status": "True",
+            "reason": "ResourcesNotSynced",
+            "message": "{{ .Resource.Condition.Reason }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"bucket-a": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "s3.aws.upbound.io/v1beta1",
+	"kind": "Bucket",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "AccessDenied"
+			}
+		]
+	}
+}`),
+							},
+							"bucket-b": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "s3.aws.upbound.io/v1beta1",
+	"kind": "Bucket",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "NotFound"
+			}
+		]
+	}
+}`),
+							},
+							"bucket-c": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "s3.aws.upbound.io/v1beta1",
+	"kind": "Bucket",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "AccessDenied"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Degraded",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "ResourcesNotSynced",
+							Message: ptr.To("AccessDenied, NotFound"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"NativeExtraResourceConditions": {
+			reason: "The function should match the conditions of a native Kubernetes object supplied as an extra resource.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "extraResourcesOnly": true,
+          "extraResources": [
+            {
+              "name": "^deployments\\.default/app$"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Available",
+              "status": "True",
+              "reason": "MinimumReplicasAvailable",
+              "message": "^(?P<Message>.+)$",
+              "transitionTime": {
+                "operator": "After",
+                "type": "Progressing"
+              }
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "AppAvailable",
+            "status": "True",
+            "reason": "Available",
+            "message": "{{ .Message }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					ExtraResources: map[string]*fnv1.Resources{
+						"deployments": {
+							Items: []*fnv1.Resource{
+								{
+									Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "apps/v1",
+	"kind": "Deployment",
+	"metadata": {
+		"name": "app",
+		"namespace": "default",
+		"generation": 3
+	},
+	"status": {
+		"observedGeneration": 3,
+		"replicas": 2,
+		"updatedReplicas": 2,
+		"readyReplicas": 2,
+		"availableReplicas": 2,
+		"conditions": [
+			{
+				"type": "Progressing",
+				"status": "True",
+				"lastUpdateTime": "2024-01-01T10:01:00Z",
+				"lastTransitionTime": "2024-01-01T10:00:00Z",
+				"reason": "NewReplicaSetAvailable",
+				"message": "ReplicaSet \"app-5d9c7b\" has successfully progressed."
+			},
+			{
+				"type": "Available",
+				"status": "True",
+				"lastUpdateTime": "2024-01-01T10:02:00Z",
+				"lastTransitionTime": "2024-01-01T10:02:00Z",
+				"reason": "MinimumReplicasAvailable",
+				"message": "Deployment has minimum availability."
+			}
+		]
+	}
+}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "AppAvailable",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "Available",
+							Message: ptr.To("Deployment has minimum availability."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MaxEvents": {
+			reason: "The function should stop creating events once maxEvents is reached, and report how many were dropped.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "maxEvents": 2,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "target": "Composite",
+          "event": {
+            "reason": "Event0",
+            "message": "Event 0."
+          }
+        },
+        {
+          "target": "Composite",
+          "event": {
+            "reason": "Event1",
+            "message": "Event 1."
+          }
+        },
+        {
+          "target": "Composite",
+          "event": {
+            "reason": "Event2",
+            "message": "Event 2."
+          }
+        },
+        {
+          "target": "Composite",
+          "event": {
+            "reason": "Event3",
+            "message": "Event 3."
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "example.org/v1",
+	"kind": "XR",
+	"metadata": {
+		"name": "example-xr"
+	}
+}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Reason:   ptr.To("Event0"),
+							Message:  "Event 0.",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Reason:   ptr.To("Event1"),
+							Message:  "Event 1.",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Reason:   ptr.To("TooManyEvents"),
+							Message:  "2 more events were not created, maxEvents: 2",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"SuccessConditionTarget": {
+			reason: "The function should set the success condition on the configured target.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "successConditionTarget": "CompositeAndClaim",
+  "statusConditionHooks": []
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"FailureConditionTarget": {
+			reason: "The function should set a failed success condition on the configured target.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "successConditionTarget": "CompositeAndClaim",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Ready",
+            "status": "Maybe",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "SetConditionFailure",
+							Message: ptr.To("cannot set condition, statusConditionHookIndex: 0, setConditionIndex: 0: invalid status \"Maybe\", must be one of [True, False, Unknown]"),
+							Target:  fnv1.Target_TARGET_COMPOSITE_AND_CLAIM.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceNameCaptureGroups": {
+			reason: "The function should make the groups captured from resource names available to templates.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "resources": [
+            {
+              "name": "^policy-(?P<Index>\\d+)$"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "^(?P<Error>.+)$"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "PoliciesSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "Policy #{{ .Index }} failed: {{ .Error }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"policy-1": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "iam.aws.upbound.io/v1beta1",
+	"kind": "Policy",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "True",
+				"reason": "ReconcileError",
+				"message": ""
+			}
+		]
+	}
+}`),
+							},
+							"policy-3": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "iam.aws.upbound.io/v1beta1",
+	"kind": "Policy",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "malformed policy document"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "PoliciesSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("Policy #3 failed: malformed policy document"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MessageJSON": {
+			reason: "The function should capture fields from a JSON condition message.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "bucket"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "messageJSON": {
+                "Code": "error.code",
+                "Detail": "error.details[0].message"
+              }
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "BucketSynced",
+            "status": "False",
+            "reason": "ReconcileError",
+            "message": "Error {{ .Code }}: {{ .Detail }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"bucket": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "s3.aws.upbound.io/v1beta1",
+	"kind": "Bucket",
+	"status": {
+		"conditions": [
+			{
+				"type": "Synced",
+				"status": "False",
+				"reason": "ReconcileError",
+				"message": "{\"error\": {\"code\": 403, \"details\": [{\"message\": \"access denied\"}]}}"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "BucketSynced",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "ReconcileError",
+							Message: ptr.To("Error 403: access denied"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"NoResourceHasReason": {
+			reason: "The function should set a condition when no resource has a forbidden reason.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "maxMatches": 0,
+          "resources": [
+            {
+              "name": "mr-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "reason": "ProvisioningFailed"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Ready",
+            "status": "True",
+            "reason": "NoProvisioningFailures"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"mr-0": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+							"mr-1": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "Creating"
+			}
+		]
+	}
+}`),
+							},
+							"mr-2": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Ready",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "NoProvisioningFailures",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"OneResourceHasReason": {
+			reason: "The function should not set a condition when any resource has a forbidden reason.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "maxMatches": 0,
+          "resources": [
+            {
+              "name": "mr-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "reason": "ProvisioningFailed"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "Ready",
+            "status": "True",
+            "reason": "NoProvisioningFailures"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"mr-0": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+							"mr-1": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "False",
+				"reason": "ProvisioningFailed"
+			}
+		]
+	}
+}`),
+							},
+							"mr-2": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"status": {
+		"conditions": [
+			{
+				"type": "Ready",
+				"status": "True",
+				"reason": "Available"
+			}
+		]
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CustomStatusToSeverity": {
+			reason: "A statusToSeverity mapping should override the default event type for a matched condition status.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusToSeverity": {
+    "False": "Normal",
+    "Unknown": "Warning"
+  },
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "event": {
+            "reason": "NotSynced",
+            "message": "The resource is not synced."
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "Unknown"
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "event": {
+            "reason": "ReadyUnknown",
+            "message": "The resource readiness is unknown."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "example-name"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced"
+      },
+      {
+        "status": "Unknown",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "The resource is not synced.",
+							Reason:   ptr.To("NotSynced"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  "The resource readiness is unknown.",
+							Reason:   ptr.To("ReadyUnknown"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MatcherRefs": {
+			reason: "Named matchers referenced by a hook should be ANDed with the hook's own matchers.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "namedMatchers": {
+    "databaseSynced": [
+      {
+        "resources": [
+          {
+            "name": "db-.*"
+          }
+        ],
+        "conditions": [
+          {
+            "type": "Synced",
+            "status": "True"
+          }
+        ]
+      }
+    ],
+    "networkSynced": [
+      {
+        "resources": [
+          {
+            "name": "network"
+          }
+        ],
+        "conditions": [
+          {
+            "type": "Synced",
+            "status": "True"
+          }
+        ]
+      }
+    ]
+  },
+  "statusConditionHooks": [
+    {
+      "matcherRefs": ["databaseSynced"],
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "db-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matcherRefs": ["databaseSynced"],
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "db-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseNotReady",
+            "status": "True",
+            "reason": "Unavailable"
+          }
+        }
+      ]
+    },
+    {
+      "matcherRefs": ["networkSynced"],
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "db-.*"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "NetworkReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"db-a": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "db-a"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      },
+      {
+        "status": "True",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "DatabaseReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"UnknownMatcherRef": {
+			reason: "A hook referencing named matchers that don't exist should fail.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matcherRefs": ["databaseSynced"],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "InputFailure",
+							Message: ptr.To(`cannot find named matchers "databaseSynced", statusConditionHookIndex: 0`),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"SetConditionPreconditions": {
+			reason: "A condition should only be set if the composite resource matches its preconditions.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "CustomReady",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "preconditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ],
+          "condition": {
+            "type": "CustomSynced",
+            "status": "True",
+            "reason": "Available"
+          }
+        },
+        {
+          "preconditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            },
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ],
+          "condition": {
+            "type": "CustomReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "example.org/v1",
+  "kind": "XR",
+  "metadata": {
+    "name": "example-xr"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      },
+      {
+        "status": "False",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "CustomSynced",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MatchOwnerReferences": {
+			reason: "A matcher should only evaluate resources with a matching owner reference.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": ".*"
+            }
+          ],
+          "matchOwnerReferences": [
+            {
+              "kind": "^XDatabase$"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database-user": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "database-user",
+    "ownerReferences": [
+      {
+        "apiVersion": "example.org/v1",
+        "kind": "XDatabase",
+        "name": "database-abc",
+        "uid": "1"
+      }
+    ]
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+							"network-subnet": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "network-subnet",
+    "ownerReferences": [
+      {
+        "apiVersion": "example.org/v1",
+        "kind": "XNetwork",
+        "name": "network-abc",
+        "uid": "2"
+      }
+    ]
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "DatabaseReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceConditionCountTemplate": {
+			reason: "The number of conditions of the first matched resource should be available to templates.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "{{ .ResourceConditionCount }} conditions observed on {{ (index .MatchedResources 0).Key }}."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "example-name"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      },
+      {
+        "status": "False",
+        "type": "Ready"
+      },
+      {
+        "status": "True",
+        "type": "Healthy"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("3 conditions observed on example-mr."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ConsistentConditions": {
+			reason: "A matcher should only match resources whose conditions agree.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "stable-mr"
+            }
+          ],
+          "consistentConditions": {
+            "types": ["Synced", "Ready"],
+            "status": "True"
+          }
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "StableReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "catching-up-mr"
+            }
+          ],
+          "consistentConditions": {
+            "types": ["Synced", "Ready"],
+            "status": "True"
+          }
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "CatchingUpReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"stable-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      },
+      {
+        "status": "True",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+							"catching-up-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      },
+      {
+        "status": "False",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StableReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ReservedConditionType": {
+			reason: "A setCondition of the reserved StatusTransformationSuccess type should be ignored with a warning.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "StatusTransformationSuccess",
+            "status": "False",
+            "reason": "Custom"
+          }
+        },
+        {
+          "condition": {
+            "type": "CustomReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  "ignoring setCondition of reserved condition type StatusTransformationSuccess, statusConditionHookIndex: 0, setConditionIndex: 0",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "CustomReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ConditionRefs": {
+			reason: "Named conditions referenced by matchers of several hooks should be matched along with their own conditions.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "namedConditions": {
+    "healthy": [
+      {
+        "type": "Synced",
+        "status": "True"
+      },
+      {
+        "type": "Ready",
+        "status": "True"
+      }
+    ]
+  },
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditionRefs": ["healthy"]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "bucket"
+            }
+          ],
+          "conditionRefs": ["healthy"]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "BucketReady",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditionRefs": ["healthy"],
+          "conditions": [
+            {
+              "type": "Backed",
+              "status": "True"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseBackedUp",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      },
+      {
+        "status": "True",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+							"bucket": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      },
+      {
+        "status": "False",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "DatabaseReady",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"UnknownConditionRef": {
+			reason: "A matcher referencing named conditions that don't exist should fail.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditionRefs": ["healthy"]
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "InputFailure",
+							Message: ptr.To(`cannot find named conditions "healthy", statusConditionHookIndex: 0, matchConditionIndex: 0`),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"HookEventTTL": {
+			reason: "The event TTL of a matched hook should only shorten the response TTL when the hook creates an event.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "eventTTL": "10s",
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "event": {
+            "type": "Warning",
+            "reason": "Transient",
+            "message": "Something transient happened."
+          }
+        }
+      ]
+    },
+    {
+      "eventTTL": "1s",
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "NoEvents",
+            "status": "True",
+            "reason": "Available"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(10 * time.Second)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  "Something transient happened.",
+							Reason:   ptr.To("Transient"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "NoEvents",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"AbsentResourceMatched": {
+			reason: "A matcher for an absent resource combined with a condition matcher should match when the resource is absent and the other resource is failing.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "cache"
+            }
+          ],
+          "absent": true
+        },
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "CacheReady",
+            "status": "False",
+            "reason": "Missing",
+            "message": "cache is missing and database is not synced"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "database"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CacheReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Missing",
+							Message: ptr.To("cache is missing and database is not synced"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"AbsentResourceNotMatched": {
+			reason: "A matcher for an absent resource should not match when the resource exists.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "cache"
+            }
+          ],
+          "absent": true
+        },
+        {
+          "resources": [
+            {
+              "name": "database"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "CacheReady",
+            "status": "False",
+            "reason": "Missing",
+            "message": "cache is missing and database is not synced"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"cache": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "cache"
+  }
+}`),
+							},
+							"database": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "database"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceObjectTemplate": {
+			reason: "The whole object of the first matched resource should be available to templates.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "Database in {{ .Resource.spec.forProvider.region }} is not ready."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "example-name"
+  },
+  "spec": {
+    "forProvider": {
+      "region": "us-east-1"
+    }
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("Database in us-east-1 is not ready."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MessagePrefixAndSuffix": {
+			reason: "The message prefix and suffix should be added to rendered condition messages.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "messagePrefix": "[platform] ",
+  "messageSuffix": " (owned by the platform team)",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "Database in {{ .Resource.spec.forProvider.region }} is not ready."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "example-name"
+  },
+  "spec": {
+    "forProvider": {
+      "region": "us-east-1"
+    }
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("[platform] Database in us-east-1 is not ready. (owned by the platform team)"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EmitSuccessEvent": {
+			reason: "The function should create a Normal event when emitSuccessEvent is set and no hook failed.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "emitSuccessEvent": true,
+  "successEventMessage": "Evaluated the status of {{ .XRName }}",
+  "statusConditionHooks": []
+}
+`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "example.org/v1",
+	"kind": "XR",
+	"metadata": {
+		"name": "my-xr"
+	}
+}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "Evaluated the status of my-xr",
+							Reason:   ptr.To("Available"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EmitSuccessEventNotOnFailure": {
+			reason: "The function should not create a success event when a hook failed.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "emitSuccessEvent": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "message": "(?!"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "CustomSynced",
+            "status": "False",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	}
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MatchFailure",
+							Message: ptr.To("cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: cannot compile message regex: error parsing regexp: invalid or unsupported Perl syntax: `(?!`"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CapturesIndex": {
+			reason: "Capture groups should be available under Captures so that they can be looked up with index.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "failed with code (?P<Code>\\d+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "Error code {{ index .Captures (printf \"%s\" \"Code\") }}, {{ .Code }}."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "example-name"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced",
+        "message": "failed with code 403"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("Error code 403, 403."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ConditionsPath": {
+			reason: "Conditions should be read from the conditionsPath of the matcher.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditionsPath": "status.health.conditions",
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "message": "failed with code (?P<Code>\\d+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "Error code {{ .Code }} from {{ .Resource.Condition.Type }}."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "name": "example-name"
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced"
+      }
+    ],
+    "health": {
+      "conditions": [
+        {
+          "status": "False",
+          "type": "Synced",
+          "message": "failed with code 403"
+        }
+      ]
+    }
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("Error code 403 from Synced."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CompareResourceCountTemplate": {
+			reason: "The number of selected resources should be compared to the expected count and be available to templates.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "CompareResourceCount",
+          "resources": [
+            {
+              "name": "^Replica/.*",
+              "key": "KindAndName"
+            }
+          ],
+          "resourceCount": {
+            "operator": "LessThan",
+            "count": 3
+          }
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "ReplicasAvailable",
+            "status": "False",
+            "reason": "MissingReplicas",
+            "message": "Expected 3 replicas but only {{ .ResourceCount }} exist."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"replica-a": {
+								Resource: resource.MustStructJSON(`{"apiVersion": "some.example.com/v1alpha1", "kind": "Replica"}`),
+							},
+							"replica-b": {
+								Resource: resource.MustStructJSON(`{"apiVersion": "some.example.com/v1alpha1", "kind": "Replica"}`),
+							},
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion": "some.example.com/v1alpha1", "kind": "Database"}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "ReplicasAvailable",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MissingReplicas",
+							Message: ptr.To("Expected 3 replicas but only 2 exist."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ReportNoMatchMatched": {
+			reason: "The success reason should be Available when a hook set a condition.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "reportNoMatch": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Checked",
+            "status": "True",
+            "reason": "Checked"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Checked",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Checked",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ReportNoMatchNoMatch": {
+			reason: "The success reason should be NoMatch when reportNoMatch is set and no hook set a condition.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "reportNoMatch": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": true
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Checked",
+            "status": "True",
+            "reason": "Checked"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "NoMatch",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MaxAgeWithinWindow": {
+			reason: "A condition that transitioned within maxAge should match.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "maxAge": "10m"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "RecentlyFailed",
+            "status": "True",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced",
+        "lastTransitionTime": "2024-08-02T15:55:00Z"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "RecentlyFailed",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "ReconcileError",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"MaxAgeOutsideWindow": {
+			reason: "A condition that transitioned longer ago than maxAge should not match.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False",
+              "maxAge": "10m"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "RecentlyFailed",
+            "status": "True",
+            "reason": "ReconcileError"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced",
+        "lastTransitionTime": "2024-08-02T15:40:00Z"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CoalesceDuplicateConditions": {
+			reason: "Exact duplicate conditions set by forceful setConditions of different hooks should be coalesced.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "The database is unavailable."
+          }
+        }
+      ]
+    },
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Ready",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "force": true,
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "The database is unavailable."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("The database is unavailable."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CompareWithCompositeMatched": {
+			reason: "A condition field that differs from the composite resource's condition should match NotEqual, and both conditions should be available to templates.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "compareWithComposite": {
+                "field": "Reason",
+                "operator": "NotEqual"
+              }
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Drift",
+            "status": "True",
+            "reason": "ReasonChanged",
+            "message": "{{ .Resource.Condition.Reason }} != {{ .Resource.CompositeConditions.Synced.Reason }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "example.org/v1",
+  "kind": "XR",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced",
+        "reason": "ReconcileSuccess"
+      }
+    ]
+  }
+}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced",
+        "reason": "ReconcileError"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Drift",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "ReasonChanged",
+							Message: ptr.To("ReconcileError != ReconcileSuccess"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CompareWithCompositeNotMatched": {
+			reason: "A condition field that equals the composite resource's condition should not match NotEqual.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "compareWithComposite": {
+                "field": "Reason",
+                "operator": "NotEqual"
+              }
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Drift",
+            "status": "True",
+            "reason": "ReasonChanged"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "example.org/v1",
+  "kind": "XR",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced",
+        "reason": "ReconcileSuccess"
+      }
+    ]
+  }
+}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced",
+        "reason": "ReconcileSuccess"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CompareWithCompositeInvalidField": {
+			reason: "An invalid compareWithComposite field should return a match failure.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "compareWithComposite": {
+                "field": "Severity"
+              }
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Drift",
+            "status": "True",
+            "reason": "ReasonChanged"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced",
+        "reason": "ReconcileSuccess"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "MatchFailure",
+							Message: ptr.To("cannot match resources, statusConditionHookIndex: 0, matchConditionIndex: 0: invalid compareWithComposite field Severity, must be one of [Status, Reason, Message]"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"WarnOnEmptyWithoutHooks": {
+			reason: "An input without hooks should emit a warning and the NoHooks reason when warnOnEmpty is true.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "warnOnEmpty": true,
+  "statusConditionHooks": []
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "NoHooks",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Reason:   ptr.To("NoHooks"),
+							Message:  "no hooks are configured",
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"WarnOnEmptyDisabled": {
+			reason: "An input without hooks should only set the success condition when warnOnEmpty is false.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "warnOnEmpty": false,
+  "statusConditionHooks": []
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"WarnOnEmptyWithHooks": {
+			reason: "An input with hooks should not emit a warning when warnOnEmpty is true.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "warnOnEmpty": true,
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Custom",
+            "status": "True",
+            "reason": "Matched"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Custom",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Matched",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"SpecNotYetObserved": {
+			reason: "A resource whose generation is greater than its observed generation should match specNotYetObserved, while one that is in sync should not.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "type": "AnyResourceMatchesAnyCondition",
+          "resources": [
+            {
+              "name": "example-.*"
+            }
+          ],
+          "specNotYetObserved": true
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Reconciling",
+            "status": "True",
+            "reason": "SpecNotYetObserved",
+            "message": "{{ (index .MatchedResources 0).Key }} is reconciling"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-drifting": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "generation": 4
+  },
+  "status": {
+    "observedGeneration": 3
+  }
+}`),
+							},
+							"example-in-sync": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "metadata": {
+    "generation": 2
+  },
+  "status": {
+    "observedGeneration": 2
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Reconciling",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "SpecNotYetObserved",
+							Message: ptr.To("example-drifting is reconciling"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CaptureGroupTakesPrecedence": {
+			reason: "A capture group should take precedence over a template value of the same name provided by the function.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "message": "owned by (?P<XRName>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Owner",
+            "status": "True",
+            "reason": "Captured",
+            "message": "{{ .XRName }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Composite: &fnv1.Resource{
+							Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "example.org/v1",
+  "kind": "XR",
+  "metadata": {
+    "name": "example-xr"
+  }
+}`),
+						},
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "True",
+        "type": "Synced",
+        "message": "owned by team-a"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "Owner",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "Captured",
+							Message: ptr.To("team-a"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceObjectMissingPath": {
+			reason: "Navigating into a missing or null field of the matched resource should render empty text rather than fail.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "zone: {{ .Resource.spec.forProvider.zone.name }}, secret: {{ .Resource.spec.writeConnectionSecretToRef.name }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "spec": {
+    "writeConnectionSecretToRef": null
+  },
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Ready"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("zone: , secret: "),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"ResourceCaptureGroupTakesPrecedence": {
+			reason: "A capture group named Resource should take precedence over the matched resource, also when messages are aggregated.",
+			args: args{
+				ctx: context.TODO(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False",
+              "message": "cannot create (?P<Resource>.+)"
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "DatabaseReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "cannot create {{ .Resource }}"
+          }
+        },
+        {
+          "aggregateMessages": {},
+          "condition": {
+            "type": "BucketReady",
+            "status": "False",
+            "reason": "Unavailable",
+            "message": "cannot create {{ .Resource }}"
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Ready",
+        "message": "cannot create bucket"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "DatabaseReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("cannot create bucket"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:    "BucketReady",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "Unavailable",
+							Message: ptr.To("cannot create bucket"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EventReasonNotValidatedByDefault": {
+			reason: "An event reason that does not follow the Kubernetes conventions should be used as is when validateConditionFormat is false.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "event": {
+            "type": "Warning",
+            "reason": "access-denied",
+            "message": "Access was denied."
+          }
+        }
+      ]
+    }
+  ]
+}
+		`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_WARNING,
+							Message:  "Access was denied.",
+							Reason:   ptr.To("access-denied"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EnvironmentAtDefaultKeyNotAnObject": {
+			reason: "The function should treat the environment as empty if the default function context key does not hold an object.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {
+            "type": "CustomReady",
+            "status": "True",
+            "reason": "Available",
+            "message": "Running in {{ .Env.region }}."
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Context: resource.MustStructJSON(`
+{
+	"apiextensions.crossplane.io/environment": "not-an-object"
+}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Context: resource.MustStructJSON(`
+{
+	"apiextensions.crossplane.io/environment": "not-an-object"
+}`),
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "CustomReady",
+							Status:  fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason:  "Available",
+							Message: ptr.To("Running in ."),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"EnvironmentAtCustomKeyNotAnObject": {
+			reason: "The function should fail if the configured function context key does not hold an object.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "environmentContextKey": "example.org/environment",
+  "statusConditionHooks": []
+}
+`),
+					Context: resource.MustStructJSON(`
+{
+	"example.org/environment": "not-an-object"
+}`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Context: resource.MustStructJSON(`
+{
+	"example.org/environment": "not-an-object"
+}`),
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "InputFailure",
+							Message: ptr.To("cannot get environment from function context key example.org/environment: not an object"),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"CoalesceKeepsLastDuplicate": {
+			reason: "A later hook that sets the same condition as an earlier one should override a different condition set in between.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [{"compositeOnly": true, "conditions": [{"type": "Synced", "exists": false}]}],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {"type": "Ready", "status": "False", "reason": "Unavailable"}
+        }
+      ]
+    },
+    {
+      "matchers": [{"compositeOnly": true, "conditions": [{"type": "Synced", "exists": false}]}],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "force": true,
+          "condition": {"type": "Ready", "status": "True", "reason": "Available"}
+        }
+      ]
+    },
+    {
+      "matchers": [{"compositeOnly": true, "conditions": [{"type": "Synced", "exists": false}]}],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "force": true,
+          "condition": {"type": "Ready", "status": "False", "reason": "Unavailable"}
+        }
+      ]
+    }
+  ]
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "Ready",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "Ready",
+							Status: fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason: "Unavailable",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"StatusToSeverityLowercaseKey": {
+			reason: "A statusToSeverity key should be parsed like a condition status, so that an unquoted YAML False key unmarshalled as false still applies.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusToSeverity": {
+    "false": "Normal"
+  },
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "False"
+            }
+          ]
+        }
+      ],
+      "createEvents": [
+        {
+          "event": {
+            "reason": "NotSynced",
+            "message": "The resource is not synced."
+          }
+        }
+      ]
+    }
+  ]
+}
+`),
+					Observed: &fnv1.State{
+						Resources: map[string]*fnv1.Resource{
+							"example-mr": {
+								Resource: resource.MustStructJSON(`
+{
+  "apiVersion": "some.example.com/v1alpha1",
+  "kind": "Object",
+  "status": {
+    "conditions": [
+      {
+        "status": "False",
+        "type": "Synced"
+      }
+    ]
+  }
+}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta: &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{
+						{
+							Severity: fnv1.Severity_SEVERITY_NORMAL,
+							Message:  "The resource is not synced.",
+							Reason:   ptr.To("NotSynced"),
+							Target:   fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:   "StatusTransformationSuccess",
+							Status: fnv1.Status_STATUS_CONDITION_TRUE,
+							Reason: "Available",
+							Target: fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+		"StatusToSeverityInvalidKey": {
+			reason: "A statusToSeverity key that is not a condition status should be rejected rather than silently ignored.",
+			args: args{
+				ctx: context.Background(),
+				req: &fnv1.RunFunctionRequest{
+					Meta: &fnv1.RequestMeta{Tag: "hello"},
+					Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusToSeverity": {
+    "Failed": "Warning"
+  },
+  "statusConditionHooks": []
+}
+`),
+				},
+			},
+			want: want{
+				rsp: &fnv1.RunFunctionResponse{
+					Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1.Result{},
+					Conditions: []*fnv1.Condition{
+						{
+							Type:    "StatusTransformationSuccess",
+							Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+							Reason:  "InputFailure",
+							Message: ptr.To(`cannot parse statusToSeverity: invalid status "Failed", must be one of [True, False, Unknown]`),
+							Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := &Function{log: logging.NewNopLogger(), clock: clocktesting.NewFakePassiveClock(now)}
+			rsp, err := f.RunFunction(tc.args.ctx, tc.args.req)
+
+			// The function-sdk-go library depends on the go-json-experiment
+			// library. The go-json-experiment library explicitly randomizes
+			// their error messages between "cannot unmarshal..." and "unable
+			// to unmarshal...". This is to "Hyrum-proof" (see Hyrum's Law)
+			// their library. This means that we must un-Hyrum-proof the strings or
+			// else our unit tests will occasionally fail.
+			if tc.want.cleanError {
+				for i := range rsp.GetConditions() {
+					msg := rsp.GetConditions()[i].GetMessage()
+					if msg == "" {
+						continue
+					}
+					rsp.Conditions[i].Message = ptr.To(strings.ReplaceAll(msg, "unable to unmarshal Go value", "cannot unmarshal Go value"))
+				}
+			}
+
+			if diff := cmp.Diff(tc.want.rsp, rsp, protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nf.RunFunction(...): -want rsp, +got rsp:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("%s\nf.RunFunction(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLogging(t *testing.T) {
+	input := func(logLevel string) string {
+		return `
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  ` + logLevel + `
+  "statusConditionHooks": [
+    {
+      "name": "database",
+      "matchers": [
+        {
+          "name": "synced",
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+	}
+
+	type want struct {
+		level  string
+		fields map[string]any
+	}
+
+	cases := map[string]struct {
+		reason string
+		input  string
+		want   want
+	}{
+		"DefaultLevel": {
+			reason: "Without a logLevel, debug messages should be logged at debug with the hook, matcher, and resource fields.",
+			input:  input(""),
+			want: want{
+				level: "debug",
+				fields: map[string]any{
+					"statusConditionHookIndex": 0,
+					"statusConditionHookName":  "database",
+					"matchConditionIndex":      0,
+					"matcherName":              "synced",
+					"resource":                 "example-mr",
+				},
+			},
+		},
+		"InfoLevel": {
+			reason: "A logLevel of Info should suppress debug messages.",
+			input:  input(`"logLevel": "Info",`),
+			want:   want{},
+		},
+		"DebugLevel": {
+			reason: "A logLevel of Debug should promote debug messages to info messages.",
+			input:  input(`"logLevel": "Debug",`),
+			want: want{
+				level: "info",
+				fields: map[string]any{
+					"statusConditionHookIndex": 0,
+					"statusConditionHookName":  "database",
+					"matchConditionIndex":      0,
+					"matcherName":              "synced",
+					"resource":                 "example-mr",
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := &capturingLogger{entries: &[]logEntry{}}
+			f := &Function{log: log}
+			_, err := f.RunFunction(context.Background(), &fnv1.RunFunctionRequest{
+				Input: resource.MustStructJSON(tc.input),
+				Observed: &fnv1.State{
+					Resources: map[string]*fnv1.Resource{
+						"example-mr": {
+							Resource: resource.MustStructJSON(`{"apiVersion": "some.example.com/v1alpha1", "kind": "Object"}`),
+						},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("%s\nf.RunFunction(...): unexpected error: %v", tc.reason, err)
+			}
+
+			got := want{}
+			for _, e := range *log.entries {
+				if e.msg != "selected resource" {
+					continue
+				}
+				got.level = e.level
+				got.fields = map[string]any{}
+				for k := range tc.want.fields {
+					got.fields[k] = e.fields[k]
+				}
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("%s\nf.RunFunction(...): -want log entry, +got log entry:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestZeroSelectionNote(t *testing.T) {
+	input := func(logLevel string) string {
+		return `{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  ` + logLevel + `
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "db"
+            },
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+	}
+
+	cases := map[string]struct {
+		reason string
+		input  string
+		want   []logEntry
+	}{
+		"DefaultLevel": {
+			reason: "A resource matcher that selects nothing should be noted at debug level.",
+			input:  input(""),
+			want: []logEntry{
+				{level: "debug", msg: `resource matcher "db" selected 0 resources`, fields: map[string]any{"resourcesIndex": 0}},
+			},
+		},
+		"InfoLevel": {
+			reason: "A logLevel of Info should suppress the note.",
+			input:  input(`"logLevel": "Info",`),
+		},
+		"DebugLevel": {
+			reason: "A logLevel of Debug should promote the note to an info message.",
+			input:  input(`"logLevel": "Debug",`),
+			want: []logEntry{
+				{level: "info", msg: `resource matcher "db" selected 0 resources`, fields: map[string]any{"resourcesIndex": 0}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := &capturingLogger{entries: &[]logEntry{}}
+			f := &Function{log: log}
+			_, err := f.RunFunction(context.Background(), &fnv1.RunFunctionRequest{
+				Input: resource.MustStructJSON(tc.input),
+				Observed: &fnv1.State{
+					Resources: map[string]*fnv1.Resource{
+						"example-mr": {
+							Resource: resource.MustStructJSON(`{"apiVersion": "some.example.com/v1alpha1", "kind": "Object"}`),
+						},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("%s\nf.RunFunction(...): unexpected error: %v", tc.reason, err)
+			}
+
+			var got []logEntry
+			for _, e := range *log.entries {
+				if !strings.HasSuffix(e.msg, "selected 0 resources") {
+					continue
+				}
+				got = append(got, logEntry{level: e.level, msg: e.msg, fields: map[string]any{"resourcesIndex": e.fields["resourcesIndex"]}})
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(logEntry{})); diff != "" {
+				t.Errorf("%s\nf.RunFunction(...): -want log entries, +got log entries:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMatcherResults(t *testing.T) {
+	input := func(logLevel string) string {
+		return `{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  ` + logLevel + `
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Synced",
+              "status": "True"
+            }
+          ]
+        },
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "True"
+            }
+          ]
+        },
+        {
+          "resources": [
+            {
+              "name": "example-mr"
+            }
+          ],
+          "conditions": [
+            {
+              "type": "Ready",
+              "status": "False"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+	}
+
+	cases := map[string]struct {
+		reason string
+		debug  bool
+		input  string
+		want   []logEntry
+	}{
+		"DefaultLevel": {
+			reason: "Matchers after the first that did not match should not be evaluated.",
+			input:  input(""),
+			want: []logEntry{
+				{level: "debug", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 0, "matched": true}},
+				{level: "debug", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 1, "matched": false}},
+			},
+		},
+		"DebugLevel": {
+			reason: "A logLevel of Debug should evaluate every matcher and log its result.",
+			input:  input(`"logLevel": "Debug",`),
+			want: []logEntry{
+				{level: "info", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 0, "matched": true}},
+				{level: "info", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 1, "matched": false}},
+				{level: "info", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 2, "matched": true}},
+			},
+		},
+		"DebugFlag": {
+			reason: "Running the Function with debug logs should evaluate every matcher and log its result.",
+			debug:  true,
+			input:  input(""),
+			want: []logEntry{
+				{level: "debug", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 0, "matched": true}},
+				{level: "debug", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 1, "matched": false}},
+				{level: "debug", msg: "evaluated matcher", fields: map[string]any{"matchConditionIndex": 2, "matched": true}},
+			},
+		},
+		"DebugFlagInfoLevel": {
+			reason: "A logLevel of Info should suppress matcher results even when the Function runs with debug logs.",
+			debug:  true,
+			input:  input(`"logLevel": "Info",`),
+			want:   nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := &capturingLogger{entries: &[]logEntry{}}
+			f := &Function{log: log, debug: tc.debug}
+			rsp, err := f.RunFunction(context.Background(), &fnv1.RunFunctionRequest{
+				Input: resource.MustStructJSON(tc.input),
+				Observed: &fnv1.State{
+					Resources: map[string]*fnv1.Resource{
+						"example-mr": {
+							Resource: resource.MustStructJSON(`{"apiVersion": "some.example.com/v1alpha1", "kind": "Object", "status": {"conditions": [{"type": "Synced", "status": "True"}, {"type": "Ready", "status": "False"}]}}`),
+						},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("%s\nf.RunFunction(...): unexpected error: %v", tc.reason, err)
+			}
+			if len(rsp.GetConditions()) != 1 {
+				t.Errorf("%s\nf.RunFunction(...): want only the %s condition, got %v", tc.reason, typeFunctionSuccess, rsp.GetConditions())
+			}
+
+			var got []logEntry
+			for _, e := range *log.entries {
+				if !strings.HasPrefix(e.msg, "evaluated matcher") {
+					continue
+				}
+				got = append(got, logEntry{level: e.level, msg: e.msg, fields: map[string]any{"matchConditionIndex": e.fields["matchConditionIndex"], "matched": e.fields["matched"]}})
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(logEntry{})); diff != "" {
+				t.Errorf("%s\nf.RunFunction(...): -want log entries, +got log entries:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// logEntry is a message recorded by a capturingLogger.
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+// capturingLogger is a logging.Logger that records every message it logs.
+type capturingLogger struct {
+	entries *[]logEntry
+	values  []any
+}
+
+func (l *capturingLogger) Info(msg string, keysAndValues ...any) {
+	l.record("info", msg, keysAndValues)
+}
+
+func (l *capturingLogger) Debug(msg string, keysAndValues ...any) {
+	l.record("debug", msg, keysAndValues)
+}
+
+func (l *capturingLogger) WithValues(keysAndValues ...any) logging.Logger {
+	values := append(append([]any{}, l.values...), keysAndValues...)
+	return &capturingLogger{entries: l.entries, values: values}
+}
+
+func (l *capturingLogger) record(level, msg string, keysAndValues []any) {
+	fields := map[string]any{}
+	kv := append(append([]any{}, l.values...), keysAndValues...)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[kv[i].(string)] = kv[i+1]
+	}
+	*l.entries = append(*l.entries, logEntry{level: level, msg: msg, fields: fields})
+}
+
+func TestExtraResourceNames(t *testing.T) {
+	object := func(namespace, name string) *composed.Unstructured {
+		u := composed.New()
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+
+	cases := map[string]struct {
+		reason string
+		key    string
+		r      convertedResource
+		want   []string
+	}{
+		"ClusterScoped": {
+			reason: "A cluster scoped extra resource should be selectable by its index or its name.",
+			key:    extraResourceKey + ".buckets.0",
+			r:      convertedResource{object: object("", "bucket-a")},
+			want:   []string{"buckets.0", "buckets.bucket-a"},
+		},
+		"Namespaced": {
+			reason: "A namespaced extra resource should be selectable by its index or its namespace and name.",
+			key:    extraResourceKey + ".buckets.1",
+			r:      convertedResource{object: object("team-a", "bucket-a")},
+			want:   []string{"buckets.1", "buckets.team-a/bucket-a"},
+		},
+		"Unnamed": {
+			reason: "An extra resource without a name should only be selectable by its index.",
+			key:    extraResourceKey + ".buckets.2",
+			r:      convertedResource{object: object("", "")},
+			want:   []string{"buckets.2"},
+		},
+		"ConversionFailed": {
+			reason: "An extra resource that could not be converted should only be selectable by its index.",
+			key:    extraResourceKey + ".buckets.3",
+			r:      convertedResource{err: errors.New("boom")},
+			want:   []string{"buckets.3"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := extraResourceNames(tc.key, tc.r)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nextraResourceNames(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMatchResources(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	ready := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "some.example.com/v1alpha1",
+		"kind":       "Object",
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Ready", "status": "True"},
+			},
+		},
+	}}}
+	notReady := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "some.example.com/v1alpha1",
+		"kind":       "Object",
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Ready", "status": "False"},
+			},
+		},
+	}}}
+	annotated := func(u *composed.Unstructured, step string) *composed.Unstructured {
+		a := u.DeepCopy()
+		a.SetAnnotations(map[string]string{"example.org/pipeline-step": step})
+		return a
+	}
+	generations := func(u *composed.Unstructured, generation int64, observed *int64) *composed.Unstructured {
+		g := u.DeepCopy()
+		g.SetGeneration(generation)
+		if observed != nil {
+			_ = unstructured.SetNestedField(g.Object, *observed, "status", "observedGeneration")
+		}
+		return g
+	}
+	fleet := func(notReadyCount int) map[string]convertedResource {
+		observed := map[string]convertedResource{}
+		for i := range 5 {
+			observed[fmt.Sprintf("mr-%d", i)] = convertedResource{object: ready}
+		}
+		for i := range notReadyCount {
+			observed[fmt.Sprintf("mr-%d", i)] = convertedResource{object: notReady}
+		}
+		return observed
+	}
+	trio := func(readyCount int) map[string]convertedResource {
+		observed := map[string]convertedResource{}
+		for i := range 3 {
+			observed[fmt.Sprintf("mr-%d", i)] = convertedResource{object: notReady}
+		}
+		for i := range readyCount {
+			observed[fmt.Sprintf("mr-%d", i)] = convertedResource{object: ready}
+		}
+		return observed
+	}
+	notReadyBetween := func(lower, upper int) v1beta1.Matcher {
+		return v1beta1.Matcher{
+			Type:       ptr.To(v1beta1.AnyResourceMatchesAnyCondition),
+			Resources:  []v1beta1.ResourceMatcher{{Name: "mr-.*"}},
+			Conditions: []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionFalse)}},
+			MinMatches: ptr.To(lower),
+			MaxMatches: ptr.To(upper),
+		}
+	}
+	readyPercentBetween := func(lower, upper int) v1beta1.Matcher {
+		return v1beta1.Matcher{
+			Type:              ptr.To(v1beta1.AnyResourceMatchesAnyCondition),
+			Resources:         []v1beta1.ResourceMatcher{{Name: "mr-.*"}},
+			Conditions:        []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionTrue)}},
+			MinMatchesPercent: ptr.To(lower),
+			MaxMatchesPercent: ptr.To(upper),
+		}
+	}
+	notReadyOutnumbersReady := v1beta1.Matcher{
+		Type:      ptr.To(v1beta1.CompareStatusCounts),
+		Resources: []v1beta1.ResourceMatcher{{Name: "mr-.*"}},
+		StatusCounts: &v1beta1.StatusCountComparison{
+			Type:        "Ready",
+			Status:      metav1.ConditionFalse,
+			OtherStatus: metav1.ConditionTrue,
+		},
+	}
+
+	type args struct {
+		mc       v1beta1.Matcher
+		observed map[string]convertedResource
+	}
+	type want struct {
+		matched bool
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ConversionError": {
+			reason: "A conversion error should be returned when the matcher selects the resource that failed to convert.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:  []v1beta1.ResourceMatcher{{Name: "invalid-mr"}},
+					Conditions: []v1beta1.ConditionMatcher{{Type: "Ready"}},
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: ready},
+					"invalid-mr": {err: errBoom},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, "cannot convert resource to object, resourcesIndex: 0, observedMapKey: invalid-mr"),
+			},
+		},
+		"ResourceCountUnder": {
+			reason: "Fewer selected resources than the expected count should not match Equal.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Type:      ptr.To(v1beta1.CompareResourceCount),
+					Resources: []v1beta1.ResourceMatcher{{Name: "mr-[01]"}},
+					ResourceCount: &v1beta1.ResourceCountComparison{
+						Count: 3,
+					},
+				},
+				observed: fleet(1),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"ResourceCountExact": {
+			reason: "As many selected resources as the expected count should match Equal, regardless of their conditions.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Type:      ptr.To(v1beta1.CompareResourceCount),
+					Resources: []v1beta1.ResourceMatcher{{Name: "mr-[0-2]"}},
+					ResourceCount: &v1beta1.ResourceCountComparison{
+						Count: 3,
+					},
+				},
+				observed: fleet(1),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"ResourceCountOver": {
+			reason: "More selected resources than the expected count should not match Equal.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Type:      ptr.To(v1beta1.CompareResourceCount),
+					Resources: []v1beta1.ResourceMatcher{{Name: "mr-.*"}},
+					ResourceCount: &v1beta1.ResourceCountComparison{
+						Count: 3,
+					},
+				},
+				observed: fleet(1),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"ResourceCountNoneSelected": {
+			reason: "No selected resources should be compared as a count of zero.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Type:      ptr.To(v1beta1.CompareResourceCount),
+					Resources: []v1beta1.ResourceMatcher{{Name: "db-.*"}},
+					ResourceCount: &v1beta1.ResourceCountComparison{
+						Operator: ptr.To(v1beta1.ComparisonLessThan),
+						Count:    3,
+					},
+				},
+				observed: fleet(1),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"MatchAnnotations": {
+			reason: "Only resources with the annotations should be evaluated.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:        []v1beta1.ResourceMatcher{{Name: ".*"}},
+					MatchAnnotations: map[string]string{"example.org/pipeline-step": "database"},
+					Conditions:       []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionFalse)}},
+				},
+				observed: map[string]convertedResource{
+					"network":  {object: annotated(ready, "network")},
+					"database": {object: annotated(notReady, "database")},
+				},
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"MatchAnnotationsOtherStep": {
+			reason: "Resources without the annotations should not be evaluated.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:        []v1beta1.ResourceMatcher{{Name: ".*"}},
+					MatchAnnotations: map[string]string{"example.org/pipeline-step": "network"},
+					Conditions:       []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionFalse)}},
+				},
+				observed: map[string]convertedResource{
+					"network":  {object: annotated(ready, "network")},
+					"database": {object: annotated(notReady, "database")},
+				},
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"ConditionsPathMissing": {
+			reason: "A resource without conditions at the conditionsPath should be treated as having no conditions.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:      []v1beta1.ResourceMatcher{{Name: "example-mr"}},
+					ConditionsPath: ptr.To("status.health.conditions"),
+					Conditions:     []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionTrue)}},
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: ready},
+				},
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"KindAndNameKey": {
+			reason: "A matcher should select resources by kind and name when the key is KindAndName.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:  []v1beta1.ResourceMatcher{{Name: "^Bucket/.*", Key: ptr.To(v1beta1.ResourceKeyKindAndName)}},
+					Conditions: []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionTrue)}},
+				},
+				observed: map[string]convertedResource{
+					"storage": {object: ready, kind: "Bucket"},
+					"network": {object: notReady, kind: "Object"},
+				},
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"NameKeyIgnoresKind": {
+			reason: "A matcher should select resources by the observed resource map key alone by default.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:  []v1beta1.ResourceMatcher{{Name: "^Bucket/.*"}},
+					Conditions: []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionTrue)}},
+				},
+				observed: map[string]convertedResource{
+					"storage": {object: ready, kind: "Bucket"},
+					"network": {object: notReady, kind: "Object"},
+				},
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"ConversionFailed": {
+			reason: "A matcher that opts in to conversion failures should match the selected resource that failed to convert.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:        []v1beta1.ResourceMatcher{{Name: ".*-mr"}},
+					ConversionFailed: ptr.To(true),
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: ready},
+					"invalid-mr": {err: errBoom},
+				},
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"ConversionFailedNotSelected": {
+			reason: "A matcher that opts in to conversion failures should not match if no selected resource failed to convert.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:        []v1beta1.ResourceMatcher{{Name: "example-mr"}},
+					ConversionFailed: ptr.To(true),
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: ready},
+					"invalid-mr": {err: errBoom},
+				},
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"ConversionErrorNotSelected": {
+			reason: "A conversion error should be ignored when the matcher does not select the resource that failed to convert.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:  []v1beta1.ResourceMatcher{{Name: "example-mr"}},
+					Conditions: []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionTrue)}},
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: ready},
+					"invalid-mr": {err: errBoom},
+				},
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"BelowMatchRange": {
+			reason: "The matcher should not match when fewer resources than minMatches match.",
+			args: args{
+				mc:       notReadyBetween(1, 3),
+				observed: fleet(0),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"InMatchRange": {
+			reason: "The matcher should match when the number of matching resources is within the range.",
+			args: args{
+				mc:       notReadyBetween(1, 3),
+				observed: fleet(2),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"AboveMatchRange": {
+			reason: "The matcher should not match when more resources than maxMatches match.",
+			args: args{
+				mc:       notReadyBetween(1, 3),
+				observed: fleet(4),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"AtMinMatchesPercent": {
+			reason: "The matcher should match when exactly minMatchesPercent of the resources match.",
+			args: args{
+				mc:       readyPercentBetween(80, 100),
+				observed: fleet(1),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"BelowMinMatchesPercent": {
+			reason: "The matcher should not match when less than minMatchesPercent of the resources match.",
+			args: args{
+				mc:       readyPercentBetween(80, 100),
+				observed: fleet(2),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"AboveMaxMatchesPercent": {
+			reason: "The matcher should not match when more than maxMatchesPercent of the resources match.",
+			args: args{
+				mc:       readyPercentBetween(0, 50),
+				observed: fleet(2),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"NoneMatchWithinMatchesPercent": {
+			reason: "The matcher should match when no resources match and minMatchesPercent is zero.",
+			args: args{
+				mc:       readyPercentBetween(0, 50),
+				observed: fleet(5),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"OneThirdAtMinMatchesPercent": {
+			reason: "The matcher should match when a third of the resources match and minMatchesPercent is 33, because 33.3% is not rounded down.",
+			args: args{
+				mc:       readyPercentBetween(33, 100),
+				observed: trio(1),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"OneThirdBelowMinMatchesPercent": {
+			reason: "The matcher should not match when a third of the resources match and minMatchesPercent is 34.",
+			args: args{
+				mc:       readyPercentBetween(34, 100),
+				observed: trio(1),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"TwoThirdsAboveMaxMatchesPercent": {
+			reason: "The matcher should not match when two thirds of the resources match and maxMatchesPercent is 66, because 66.7% is not rounded down.",
+			args: args{
+				mc:       readyPercentBetween(0, 66),
+				observed: trio(2),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"TwoThirdsAtMaxMatchesPercent": {
+			reason: "The matcher should match when two thirds of the resources match and maxMatchesPercent is 67.",
+			args: args{
+				mc:       readyPercentBetween(0, 67),
+				observed: trio(2),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"InvalidMatchesPercentRange": {
+			reason: "An error should be returned when minMatchesPercent is greater than maxMatchesPercent.",
+			args: args{
+				mc:       readyPercentBetween(80, 20),
+				observed: fleet(2),
+			},
+			want: want{
+				err: errors.New("minMatchesPercent 80 cannot be greater than maxMatchesPercent 20"),
+			},
+		},
+		"FailingOutnumbersHealthy": {
+			reason: "The matcher should match when more resources have the status than the other status.",
+			args: args{
+				mc:       notReadyOutnumbersReady,
+				observed: fleet(3),
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"HealthyOutnumbersFailing": {
+			reason: "The matcher should not match when fewer resources have the status than the other status.",
+			args: args{
+				mc:       notReadyOutnumbersReady,
+				observed: fleet(2),
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"MissingStatusCounts": {
+			reason: "An error should be returned when the CompareStatusCounts type is used without statusCounts.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Type:      ptr.To(v1beta1.CompareStatusCounts),
+					Resources: []v1beta1.ResourceMatcher{{Name: "mr-.*"}},
+				},
+				observed: fleet(3),
+			},
+			want: want{
+				err: errors.New("statusCounts is required when type is CompareStatusCounts"),
+			},
+		},
+		"InvalidMatchRange": {
+			reason: "An error should be returned when minMatches is greater than maxMatches.",
+			args: args{
+				mc:       notReadyBetween(3, 1),
+				observed: fleet(2),
+			},
+			want: want{
+				err: errors.New("minMatches 3 cannot be greater than maxMatches 1"),
+			},
+		},
+		"SpecNotYetObserved": {
+			reason: "A resource whose generation is greater than its observed generation should match specNotYetObserved.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:          []v1beta1.ResourceMatcher{{Name: "example-mr"}},
+					SpecNotYetObserved: ptr.To(true),
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: generations(ready, 3, ptr.To[int64](2))},
+				},
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"SpecObserved": {
+			reason: "A resource whose generation equals its observed generation should not match specNotYetObserved.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:          []v1beta1.ResourceMatcher{{Name: "example-mr"}},
+					SpecNotYetObserved: ptr.To(true),
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: generations(ready, 3, ptr.To[int64](3))},
+				},
+			},
+			want: want{
+				matched: false,
+			},
+		},
+		"SpecObservedWithConditions": {
+			reason: "A resource whose generation equals its observed generation should match specNotYetObserved false alongside its conditions.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:          []v1beta1.ResourceMatcher{{Name: "example-mr"}},
+					Conditions:         []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionTrue)}},
+					SpecNotYetObserved: ptr.To(false),
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: generations(ready, 3, ptr.To[int64](3))},
+				},
+			},
+			want: want{
+				matched: true,
+			},
+		},
+		"SpecNotYetObservedWithoutObservedGeneration": {
+			reason: "A resource without an observed generation should be treated as observed.",
+			args: args{
+				mc: v1beta1.Matcher{
+					Resources:          []v1beta1.ResourceMatcher{{Name: "example-mr"}},
+					SpecNotYetObserved: ptr.To(true),
+				},
+				observed: map[string]convertedResource{
+					"example-mr": {object: generations(ready, 3, nil)},
+				},
+			},
+			want: want{
+				matched: false,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), logKey, logging.NewNopLogger())
+			mr, err := matchResources(ctx, tc.args.mc, tc.args.observed, nil, &resource.Composite{Resource: &composite.Unstructured{}}, matchOptions{})
+
+			if diff := cmp.Diff(tc.want.matched, mr.matched); diff != "" {
+				t.Errorf("%s\nmatchResources(...): -want matched, +got matched:\n%s", tc.reason, diff)
+			}
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nmatchResources(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUnmatchedResources(t *testing.T) {
+	object := func(status string) *composed.Unstructured {
+		return &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "some.example.com/v1alpha1",
+			"kind":       "Object",
+			"status": map[string]any{
+				"conditions": []any{
+					map[string]any{"type": "Ready", "status": status},
+				},
+			},
+		}}}
+	}
+	observed := map[string]convertedResource{
+		"mr-0": {object: object("False")},
+		"mr-1": {object: object("True")},
+		"mr-2": {object: object("False")},
+	}
+	matcher := func(mt v1beta1.MatchType) v1beta1.Matcher {
+		return v1beta1.Matcher{
+			Type:       ptr.To(mt),
+			Resources:  []v1beta1.ResourceMatcher{{Name: "mr-.*"}},
+			Conditions: []v1beta1.ConditionMatcher{{Type: "Ready", Status: ptr.To(metav1.ConditionTrue)}},
+		}
+	}
+
+	type want struct {
+		matched   bool
+		unmatched []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		mc     v1beta1.Matcher
+		want   want
+	}{
+		"AllResourcesMatchAllConditions": {
+			reason: "Every resource that did not match should be listed when not all resources match all conditions.",
+			mc:     matcher(v1beta1.AllResourcesMatchAllConditions),
+			want:   want{matched: false, unmatched: []string{"mr-0", "mr-2"}},
+		},
+		"AllResourcesMatchAnyCondition": {
+			reason: "Every resource that did not match should be listed when not all resources match any condition.",
+			mc:     matcher(v1beta1.AllResourcesMatchAnyCondition),
+			want:   want{matched: false, unmatched: []string{"mr-0", "mr-2"}},
+		},
+		"AnyResourceMatchesAnyCondition": {
+			reason: "The resources that did not match should be listed when any resource matched.",
+			mc:     matcher(v1beta1.AnyResourceMatchesAnyCondition),
+			want:   want{matched: true, unmatched: []string{"mr-0", "mr-2"}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), logKey, logging.NewNopLogger())
+			mr, err := matchResources(ctx, tc.mc, observed, nil, &resource.Composite{Resource: &composite.Unstructured{}}, matchOptions{})
+			if err != nil {
+				t.Fatalf("%s\nmatchResources(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.matched, mr.matched); diff != "" {
+				t.Errorf("%s\nmatchResources(...): -want matched, +got matched:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.unmatched, resourceKeys(unmatched(mr, tc.mc.Conditions))); diff != "" {
+				t.Errorf("%s\nunmatched(...): -want keys, +got keys:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func BenchmarkRunFunction(b *testing.B) {
+	resources := map[string]*fnv1.Resource{}
+	for i := range 50 {
+		resources[fmt.Sprintf("example-mr-%d", i)] = &fnv1.Resource{
+			Resource: resource.MustStructJSON(`
+{
+	"apiVersion": "some.example.com/v1alpha1",
+	"kind": "Object",
+	"metadata": {
+		"name": "example-name"
+	},
+	"status": {
+		"conditions": [
+			{
+				"status": "True",
+				"type": "Synced"
+			},
+			{
+				"status": "True",
+				"type": "Ready"
+			}
+		]
+	}
+}`),
+		}
+	}
+
+	// Several matchers select overlapping resources.
+	req := &fnv1.RunFunctionRequest{
+		Input: resource.MustStructJSON(`
+{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "resources": [{"name": "example-mr-.*"}],
+          "conditions": [{"type": "Synced", "status": "True"}]
+        },
+        {
+          "resources": [{"name": "example-mr-1.*"}],
+          "conditions": [{"type": "Ready", "status": "True"}]
+        },
+        {
+          "resources": [{"name": "example-mr-.*"}],
+          "conditions": [{"type": "Ready", "status": "True"}]
+        }
+      ],
+      "setConditions": [
+        {
+          "target": "Composite",
+          "condition": {"type": "AllReady", "status": "True", "reason": "Available"}
+        }
+      ]
+    }
+  ]
+}`),
+		Observed: &fnv1.State{Resources: resources},
+	}
+
+	f := &Function{log: logging.NewNopLogger()}
+	b.ResetTimer()
+	for range b.N {
+		if _, err := f.RunFunction(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRollupReadinessUnknownWhileCreating(t *testing.T) {
+	withConditions := func(cs ...xpv1.Condition) convertedResource {
+		o := composed.New()
+		o.SetConditions(cs...)
+		return convertedResource{object: o}
+	}
+	ready := withConditions(
+		xpv1.Condition{Type: xpv1.TypeSynced, Status: corev1.ConditionTrue},
+		xpv1.Condition{Type: xpv1.TypeReady, Status: corev1.ConditionTrue},
+	)
+	creating := withConditions(
+		xpv1.Condition{Type: xpv1.TypeSynced, Status: corev1.ConditionTrue},
+		xpv1.Condition{Type: xpv1.TypeReady, Status: corev1.ConditionFalse, Reason: xpv1.ReasonCreating},
+	)
+	failing := withConditions(
+		xpv1.Condition{Type: xpv1.TypeSynced, Status: corev1.ConditionFalse, Reason: xpv1.ReasonReconcileError},
+		xpv1.Condition{Type: xpv1.TypeReady, Status: corev1.ConditionFalse, Reason: xpv1.ReasonCreating},
+	)
+	pending := withConditions()
+
+	type want struct {
+		status  fnv1.Status
+		reason  string
+		message string
+	}
+
+	cases := map[string]struct {
+		reason   string
+		rr       v1beta1.ReadinessRollup
+		observed map[string]convertedResource
+		want     want
+	}{
+		"AllReady": {
+			reason:   "The condition should be True when every resource is ready.",
+			rr:       v1beta1.ReadinessRollup{UnknownWhileCreating: ptr.To(true)},
+			observed: map[string]convertedResource{"bucket": ready, "database": ready},
+			want:     want{status: fnv1.Status_STATUS_CONDITION_TRUE, reason: "Available"},
+		},
+		"SomeFailing": {
+			reason:   "The condition should be False when any resource is failing, even if others are still being created.",
+			rr:       v1beta1.ReadinessRollup{UnknownWhileCreating: ptr.To(true)},
+			observed: map[string]convertedResource{"bucket": failing, "database": creating, "network": ready},
+			want:     want{status: fnv1.Status_STATUS_CONDITION_FALSE, reason: "Unavailable", message: "failing resources: bucket"},
+		},
+		"SomeCreating": {
+			reason:   "The condition should be Unknown when no resource is failing but some are still being created.",
+			rr:       v1beta1.ReadinessRollup{UnknownWhileCreating: ptr.To(true)},
+			observed: map[string]convertedResource{"bucket": pending, "database": creating, "network": ready},
+			want:     want{status: fnv1.Status_STATUS_CONDITION_UNKNOWN, reason: "Creating", message: "creating resources: bucket, database"},
+		},
+		"CustomCreatingReasons": {
+			reason:   "A False condition with a reason other than the creating reasons should mark the resource as failing.",
+			rr:       v1beta1.ReadinessRollup{UnknownWhileCreating: ptr.To(true), CreatingReasons: []string{"Provisioning"}},
+			observed: map[string]convertedResource{"database": creating},
+			want:     want{status: fnv1.Status_STATUS_CONDITION_FALSE, reason: "Unavailable", message: "failing resources: database"},
+		},
+		"Disabled": {
+			reason:   "Without unknownWhileCreating, the condition should be False when any resource is not ready.",
+			rr:       v1beta1.ReadinessRollup{},
+			observed: map[string]convertedResource{"database": creating, "network": ready},
+			want:     want{status: fnv1.Status_STATUS_CONDITION_FALSE, reason: "Unavailable", message: "unready resources: database"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), logKey, logging.NewNopLogger())
+			c, err := rollupReadiness(ctx, tc.rr, tc.observed, &resource.Composite{Resource: composite.New()}, transformOptions{})
+			if err != nil {
+				t.Fatalf("%s\nrollupReadiness(...): unexpected error: %v", tc.reason, err)
+			}
+			got := want{status: c.GetStatus(), reason: c.GetReason(), message: c.GetMessage()}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("%s\nrollupReadiness(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGetInput(t *testing.T) {
+	type args struct {
+		input              string
+		allowUnknownFields bool
+	}
+	// The errors returned by the JSON library vary, so only part of each
+	// error message is compared.
+	type want struct {
+		hooks    int
+		warnings []string
+		err      string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Valid": {
+			reason: "A StatusTransformation should be returned.",
+			args: args{
+				input: `{"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1", "kind": "StatusTransformation", "statusConditionHooks": [{}]}`,
+			},
+			want: want{hooks: 1},
+		},
+		"WrongKind": {
+			reason: "An input of another kind should be rejected before it is unmarshalled.",
+			args: args{
+				input: `{"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1", "kind": "Resources", "resources": []}`,
+			},
+			want: want{err: `unsupported input kind "Resources", expected StatusTransformation`},
+		},
+		"WrongAPIVersion": {
+			reason: "An input of another apiVersion should be rejected before it is unmarshalled.",
+			args: args{
+				input: `{"apiVersion": "function-status-transformer.fn.crossplane.io/v1alpha1", "kind": "StatusTransformation"}`,
+			},
+			want: want{err: `unsupported input apiVersion "function-status-transformer.fn.crossplane.io/v1alpha1" for kind StatusTransformation, expected function-status-transformer.fn.crossplane.io/v1beta1`},
+		},
+		"UnknownField": {
+			reason: "An input with unknown fields should be rejected.",
+			args: args{
+				input: `{"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1", "kind": "StatusTransformation", "statusConditionHook": []}`,
+			},
+			want: want{err: `unknown name "statusConditionHook"`},
+		},
+		"AllowedUnknownField": {
+			reason: "Unknown fields should be ignored and returned when they are allowed.",
+			args: args{
+				input:              `{"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1", "kind": "StatusTransformation", "statusConditionHooks": [{}], "statusConditionHook": []}`,
+				allowUnknownFields: true,
+			},
+			want: want{
+				hooks:    1,
+				warnings: []string{`unknown name "statusConditionHook"`},
+			},
+		},
+		"AllowedUnknownFieldInvalidInput": {
+			reason: "An input that is invalid for another reason should be rejected even when unknown fields are allowed.",
+			args: args{
+				input:              `{"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1", "kind": "StatusTransformation", "statusConditionHooks": "invalid"}`,
+				allowUnknownFields: true,
+			},
+			want: want{err: `[]v1beta1.StatusConditionHook`},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := &fnv1.RunFunctionRequest{Input: resource.MustStructJSON(tc.args.input)}
+			in, warnings, err := getInput(req, tc.args.allowUnknownFields)
+			if !containsError(err, tc.want.err) {
+				t.Fatalf("%s\ngetInput(...): want error containing %q, got error %v", tc.reason, tc.want.err, err)
+			}
+			if len(warnings) != len(tc.want.warnings) {
+				t.Fatalf("%s\ngetInput(...): want warnings %q, got %q", tc.reason, tc.want.warnings, warnings)
+			}
+			for i := range warnings {
+				if !strings.Contains(warnings[i], tc.want.warnings[i]) {
+					t.Errorf("%s\ngetInput(...): want warning containing %q, got %q", tc.reason, tc.want.warnings[i], warnings[i])
+				}
+			}
+			if err != nil {
+				return
+			}
+			if got := len(in.StatusConditionHooks); got != tc.want.hooks {
+				t.Errorf("%s\ngetInput(...): want %d hooks, got %d", tc.reason, tc.want.hooks, got)
+			}
+		})
+	}
+}
+
+// containsError returns true if the supplied error is nil and want is empty,
+// or if the error's message contains want.
+func containsError(err error, want string) bool {
+	if err == nil || want == "" {
+		return err == nil && want == ""
+	}
+	return strings.Contains(err.Error(), want)
+}
+
+func TestTransformConditionFormat(t *testing.T) {
+	type args struct {
+		conditionType string
+		reason        string
+		validate      bool
+	}
+
+	cases := map[string]struct {
+		reason  string
+		args    args
+		wantErr bool
+	}{
+		"Valid": {
+			reason: "A CamelCase type and reason should be valid.",
+			args:   args{conditionType: "DatabaseReady", reason: "FailedToCreate", validate: true},
+		},
+		"ValidPrefixedType": {
+			reason: "A type with a DNS subdomain prefix should be valid.",
+			args:   args{conditionType: "example.org/DatabaseReady", reason: "Failed_To:Create", validate: true},
+		},
+		"InvalidType": {
+			reason:  "A type containing spaces should be invalid.",
+			args:    args{conditionType: "Database Ready", reason: "FailedToCreate", validate: true},
+			wantErr: true,
+		},
+		"TooLongType": {
+			reason:  "A type longer than the maximum length should be invalid.",
+			args:    args{conditionType: strings.Repeat("a", maxConditionTypeLength+1), reason: "FailedToCreate", validate: true},
+			wantErr: true,
+		},
+		"InvalidReason": {
+			reason:  "A reason starting with a digit should be invalid.",
+			args:    args{conditionType: "DatabaseReady", reason: "1FailedToCreate", validate: true},
+			wantErr: true,
+		},
+		"EmptyReason": {
+			reason:  "An empty reason should be invalid.",
+			args:    args{conditionType: "DatabaseReady", reason: "", validate: true},
+			wantErr: true,
+		},
+		"NotValidated": {
+			reason: "An invalid type and reason should be accepted when validation is disabled.",
+			args:   args{conditionType: "Database Ready", reason: "failed to create"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cs := v1beta1.SetCondition{
+				Condition: v1beta1.Condition{Type: tc.args.conditionType, Status: "True", Reason: tc.args.reason},
+			}
+			_, err := transformCondition(cs, nil, nil, transformOptions{validateConditionFormat: tc.args.validate})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("%s\ntransformCondition(...): want error %t, got error %v", tc.reason, tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTransformEventType(t *testing.T) {
+	eventType := v1beta1.EventType(`{{ if eq (index .MatchedResources 0).Condition.Status "False" }}Warning{{ else }}Normal{{ end }}`)
+
+	type args struct {
+		eventType *v1beta1.EventType
+		status    corev1.ConditionStatus
+	}
+	type want struct {
+		severity fnv1.Severity
+		err      bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Default": {
+			reason: "An event without a type should be Normal.",
+			args:   args{status: corev1.ConditionFalse},
+			want:   want{severity: fnv1.Severity_SEVERITY_NORMAL},
+		},
+		"StatusFalse": {
+			reason: "A templated type should render to Warning when the matched condition is False.",
+			args:   args{eventType: &eventType, status: corev1.ConditionFalse},
+			want:   want{severity: fnv1.Severity_SEVERITY_WARNING},
+		},
+		"StatusUnknown": {
+			reason: "A templated type should render to Normal when the matched condition is Unknown.",
+			args:   args{eventType: &eventType, status: corev1.ConditionUnknown},
+			want:   want{severity: fnv1.Severity_SEVERITY_NORMAL},
+		},
+		"InvalidRenderedType": {
+			reason: "A templated type that renders to neither Normal nor Warning should be invalid.",
+			args:   args{eventType: ptr.To(v1beta1.EventType("{{ (index .MatchedResources 0).Condition.Status }}")), status: corev1.ConditionFalse},
+			want:   want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ce := v1beta1.CreateEvent{Event: v1beta1.Event{Type: tc.args.eventType, Message: "Database is unavailable."}}
+			values := map[string]any{
+				matchedResourcesTemplateKey: []matchedResource{{Key: "db", Condition: xpv1.Condition{Type: xpv1.TypeReady, Status: tc.args.status}}},
+			}
+			r, err := transformEvent(ce, values, nil, transformOptions{})
+			if gotErr := err != nil; gotErr != tc.want.err {
+				t.Fatalf("%s\ntransformEvent(...): want error %t, got error %v", tc.reason, tc.want.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if r.GetSeverity() != tc.want.severity {
+				t.Errorf("%s\ntransformEvent(...): want severity %s, got %s", tc.reason, tc.want.severity, r.GetSeverity())
+			}
+		})
+	}
+}
+
+func TestXRNamespace(t *testing.T) {
+	xr := func(metadata map[string]any) *composite.Unstructured {
+		return &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "example.org/v1",
+			"kind":       "XDatabase",
+			"metadata":   metadata,
+		}}}
+	}
+
+	cases := map[string]struct {
+		reason string
+		xr     *composite.Unstructured
+		want   string
+	}{
+		"Claimed": {
+			reason: "A claimed composite resource should return the namespace of its claim.",
+			xr:     xr(map[string]any{"name": "my-db-x7k2p", "labels": map[string]any{"crossplane.io/claim-namespace": "team-a"}}),
+			want:   "team-a",
+		},
+		"Claimless": {
+			reason: "A cluster scoped composite resource without a claim should return an empty namespace.",
+			xr:     xr(map[string]any{"name": "my-db"}),
+			want:   "",
+		},
+		"Namespaced": {
+			reason: "A namespaced composite resource without a claim should return its own namespace.",
+			xr:     xr(map[string]any{"name": "my-db", "namespace": "team-b"}),
+			want:   "team-b",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, xrNamespace(tc.xr)); diff != "" {
+				t.Errorf("%s\nxrNamespace(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCompileResourceName(t *testing.T) {
+	type args struct {
+		rm  v1beta1.ResourceMatcher
+		key string
+	}
+	type want struct {
+		matched bool
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"RegexMatchesUnanchored": {
+			reason: "A regular expression should match anywhere in the key.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "Policy-*"}, key: "MyPolicy"},
+			want:   want{matched: true},
+		},
+		"AnchoredRegexDoesNotMatchPart": {
+			reason: "An anchored regular expression should not match part of the key.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "example", Anchored: ptr.To(true)}, key: "example-mr"},
+			want:   want{matched: false},
+		},
+		"AnchoredRegexMatchesWhole": {
+			reason: "An anchored regular expression should match the whole key.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "example|other", Anchored: ptr.To(true)}, key: "other"},
+			want:   want{matched: true},
+		},
+		"GlobDoesNotMatchUnanchored": {
+			reason: "A glob should only match the whole key.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "Policy-*", MatchMode: ptr.To(v1beta1.ResourceMatchGlob)}, key: "MyPolicy"},
+			want:   want{matched: false},
+		},
+		"GlobStarMatchesAnyCharacters": {
+			reason: "A glob * should match any number of characters.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "Policy-*", MatchMode: ptr.To(v1beta1.ResourceMatchGlob)}, key: "Policy-read-only"},
+			want:   want{matched: true},
+		},
+		"GlobQuestionMarkMatchesOneCharacter": {
+			reason: "A glob ? should match exactly one character.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "db-?", MatchMode: ptr.To(v1beta1.ResourceMatchGlob)}, key: "db-10"},
+			want:   want{matched: false},
+		},
+		"GlobQuotesRegexCharacters": {
+			reason: "A glob should match regular expression characters literally.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "bucket.a", MatchMode: ptr.To(v1beta1.ResourceMatchGlob)}, key: "bucketXa"},
+			want:   want{matched: false},
+		},
+		"ExactMatchesLiterally": {
+			reason: "An exact name should match a key that equals it.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "Policy-*", MatchMode: ptr.To(v1beta1.ResourceMatchExact)}, key: "Policy-*"},
+			want:   want{matched: true},
+		},
+		"ExactDoesNotMatchPrefix": {
+			reason: "An exact name should not match a key that only contains it.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "Policy", MatchMode: ptr.To(v1beta1.ResourceMatchExact)}, key: "Policy-a"},
+			want:   want{matched: false},
+		},
+		"InvalidMatchMode": {
+			reason: "An error should be returned for an invalid match mode.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "Policy", MatchMode: ptr.To(v1beta1.ResourceMatchMode("Fuzzy"))}},
+			want:   want{err: errors.New("invalid match mode Fuzzy, must be one of [Regex, Glob, Exact]")},
+		},
+		"InvalidKey": {
+			reason: "An error should be returned for an invalid key.",
+			args:   args{rm: v1beta1.ResourceMatcher{Name: "Policy", Key: ptr.To(v1beta1.ResourceKey("Label"))}},
+			want:   want{err: errors.New("invalid key Label, must be one of [Name, KindAndName]")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			re, err := compileResourceName(tc.args.rm)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\ncompileResourceName(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.matched, re.MatchString(tc.args.key)); diff != "" {
+				t.Errorf("%s\ncompileResourceName(...): -want matched, +got matched:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestResourceNameGroups(t *testing.T) {
+	rs := map[string]conditionedObject{
+		"policy-3":           &composed.Unstructured{},
+		"role-7":             &composed.Unstructured{},
+		compositeResourceKey: &composed.Unstructured{},
+	}
+
+	type args struct {
+		resources []v1beta1.ResourceMatcher
+		policy    v1beta1.GroupConflictPolicy
+	}
+	type want struct {
+		groups map[string]map[string]string
+		err    bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NamedGroups": {
+			reason: "Named groups should be captured from the keys of the resources they match.",
+			args: args{
+				resources: []v1beta1.ResourceMatcher{{Name: `^(?P<Kind>policy|role)-(?P<Index>\d+)$`}},
+			},
+			want: want{groups: map[string]map[string]string{
+				"policy-3": {"Kind": "policy", "Index": "3"},
+				"role-7":   {"Kind": "role", "Index": "7"},
+			}},
+		},
+		"PositionalGroups": {
+			reason: "Positional groups should not be captured.",
+			args: args{
+				resources: []v1beta1.ResourceMatcher{{Name: `^policy-(\d+)$`}},
+			},
+			want: want{groups: map[string]map[string]string{}},
+		},
+		"ConflictKeep": {
+			reason: "Conflicting groups should be resolved according to the conflict policy.",
+			args: args{
+				resources: []v1beta1.ResourceMatcher{{Name: `^policy-(?P<Index>\d+)$`}, {Name: `^(?P<Index>p)olicy`}},
+				policy:    v1beta1.GroupConflictKeep,
+			},
+			want: want{groups: map[string]map[string]string{
+				"policy-3": {"Index": "3"},
+			}},
+		},
+		"ConflictError": {
+			reason: "Conflicting groups should be an error when the conflict policy is Error.",
+			args: args{
+				resources: []v1beta1.ResourceMatcher{{Name: `^policy-(?P<Index>\d+)$`}, {Name: `^(?P<Index>p)olicy`}},
+				policy:    v1beta1.GroupConflictError,
+			},
+			want: want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := resourceNameGroups(v1beta1.Matcher{Resources: tc.args.resources}, rs, tc.args.policy)
+			if gotErr := err != nil; gotErr != tc.want.err {
+				t.Fatalf("%s\nresourceNameGroups(...): want error %t, got error %v", tc.reason, tc.want.err, err)
+			}
+			if diff := cmp.Diff(tc.want.groups, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nresourceNameGroups(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFilterPresence(t *testing.T) {
+	object := &composed.Unstructured{}
+	observed := map[string]convertedResource{
+		"removed-mr":  {object: object},
+		"existing-mr": {object: object},
+	}
+	desired := map[string]conditionedObject{
+		"existing-mr":        object,
+		"created-mr":         object,
+		compositeResourceKey: object,
+	}
+	rs := map[string]conditionedObject{
+		"removed-mr":                object,
+		"existing-mr":               object,
+		"created-mr":                object,
+		compositeResourceKey:        object,
+		"extra-resources.buckets.0": object,
+	}
+
+	type want struct {
+		keys []string
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason   string
+		presence v1beta1.ResourcePresence
+		want     want
+	}{
+		"ObservedOnly": {
+			reason:   "Resources that are observed but not desired, and extra resources, should be present in the observed state only.",
+			presence: v1beta1.PresentInObservedOnly,
+			want:     want{keys: []string{"extra-resources.buckets.0", "removed-mr"}},
+		},
+		"DesiredOnly": {
+			reason:   "Resources that are desired but not observed should be present in the desired state only.",
+			presence: v1beta1.PresentInDesiredOnly,
+			want:     want{keys: []string{"created-mr"}},
+		},
+		"Both": {
+			reason:   "Resources that are observed and desired, and the composite resource, should be present in both.",
+			presence: v1beta1.PresentInBoth,
+			want:     want{keys: []string{"existing-mr", compositeResourceKey}},
+		},
+		"Invalid": {
+			reason:   "An error should be returned for an invalid presence.",
+			presence: v1beta1.ResourcePresence("Nowhere"),
+			want:     want{err: errors.New("invalid presentIn Nowhere, must be one of [ObservedOnly, DesiredOnly, Both]")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := filterPresence(rs, tc.presence, observed, desired)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nfilterPresence(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.keys, sortedKeys(got)); diff != "" {
+				t.Errorf("%s\nfilterPresence(...): -want keys, +got keys:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFilterOwned(t *testing.T) {
+	owned := func(refs ...metav1.OwnerReference) conditionedObject {
+		o := composed.New()
+		o.SetOwnerReferences(refs)
+		return o
+	}
+	rs := map[string]conditionedObject{
+		"network-subnet": owned(metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "XNetwork", Name: "network-abc", Controller: ptr.To(true)}),
+		"database-user":  owned(metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "XDatabase", Name: "database-abc", Controller: ptr.To(true)}),
+		"shared-policy": owned(
+			metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "XDatabase", Name: "database-abc", Controller: ptr.To(true)},
+			metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "XNetwork", Name: "network-abc"},
+		),
+		"unowned": owned(),
+	}
+
+	type want struct {
+		keys []string
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		orms   []v1beta1.OwnerReferenceMatcher
+		want   want
+	}{
+		"Kind": {
+			reason: "Resources with an owner reference of the supplied kind should be kept.",
+			orms:   []v1beta1.OwnerReferenceMatcher{{Kind: ptr.To("^XNetwork$")}},
+			want:   want{keys: []string{"network-subnet", "shared-policy"}},
+		},
+		"KindAndController": {
+			reason: "Resources should only be kept if a single owner reference matches all fields.",
+			orms:   []v1beta1.OwnerReferenceMatcher{{Kind: ptr.To("^XNetwork$"), Controller: ptr.To(true)}},
+			want:   want{keys: []string{"network-subnet"}},
+		},
+		"AnyMatcher": {
+			reason: "Resources with an owner reference matched by any matcher should be kept.",
+			orms: []v1beta1.OwnerReferenceMatcher{
+				{Kind: ptr.To("^XDatabase$")},
+				{Name: ptr.To("^network-")},
+			},
+			want: want{keys: []string{"database-user", "network-subnet", "shared-policy"}},
+		},
+		"APIVersion": {
+			reason: "Resources with an owner reference of the supplied apiVersion should be kept.",
+			orms:   []v1beta1.OwnerReferenceMatcher{{APIVersion: ptr.To(`^example\.com/`)}},
+			want:   want{keys: []string{}},
+		},
+		"InvalidRegex": {
+			reason: "An error should be returned for an invalid regular expression.",
+			orms:   []v1beta1.OwnerReferenceMatcher{{Kind: ptr.To("(")}},
+			want:   want{err: cmpopts.AnyError},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := filterOwned(rs, tc.orms)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("%s\nfilterOwned(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.keys, sortedKeys(got), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nfilterOwned(...): -want keys, +got keys:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFilterConsistent(t *testing.T) {
+	withConditions := func(cs ...xpv1.Condition) conditionedObject {
+		o := composed.New()
+		o.SetConditions(cs...)
+		return o
+	}
+	rs := map[string]conditionedObject{
+		"consistent-true": withConditions(
+			xpv1.Condition{Type: "Synced", Status: corev1.ConditionTrue},
+			xpv1.Condition{Type: "Ready", Status: corev1.ConditionTrue},
+		),
+		"consistent-false": withConditions(
+			xpv1.Condition{Type: "Synced", Status: corev1.ConditionFalse},
+			xpv1.Condition{Type: "Ready", Status: corev1.ConditionFalse},
+		),
+		"inconsistent": withConditions(
+			xpv1.Condition{Type: "Synced", Status: corev1.ConditionTrue},
+			xpv1.Condition{Type: "Ready", Status: corev1.ConditionFalse},
+		),
+		"missing-ready": withConditions(
+			xpv1.Condition{Type: "Synced", Status: corev1.ConditionTrue},
+		),
+	}
+
+	type want struct {
+		keys []string
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		cc     v1beta1.ConsistentConditions
+		want   want
+	}{
+		"AnyStatus": {
+			reason: "Resources whose conditions all share a status should be kept.",
+			cc:     v1beta1.ConsistentConditions{Types: []string{"Synced", "Ready"}},
+			want:   want{keys: []string{"consistent-false", "consistent-true"}},
+		},
+		"SpecifiedStatus": {
+			reason: "Resources whose conditions all have the supplied status should be kept.",
+			cc:     v1beta1.ConsistentConditions{Types: []string{"Synced", "Ready"}, Status: ptr.To(metav1.ConditionStatus("true"))},
+			want:   want{keys: []string{"consistent-true"}},
+		},
+		"SingleType": {
+			reason: "A single type should only require the condition to be present.",
+			cc:     v1beta1.ConsistentConditions{Types: []string{"Synced"}},
+			want:   want{keys: []string{"consistent-false", "consistent-true", "inconsistent", "missing-ready"}},
+		},
+		"NoTypes": {
+			reason: "An error should be returned if no types are supplied.",
+			cc:     v1beta1.ConsistentConditions{},
+			want:   want{err: errors.New("consistentConditions must have at least one type")},
+		},
+		"InvalidStatus": {
+			reason: "An error should be returned for an invalid status.",
+			cc:     v1beta1.ConsistentConditions{Types: []string{"Ready"}, Status: ptr.To(metav1.ConditionStatus("Maybe"))},
+			want:   want{err: errors.Wrap(errors.New(`invalid status "Maybe", must be one of [True, False, Unknown]`), "cannot parse consistentConditions status")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := filterConsistent(rs, tc.cc)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nfilterConsistent(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.keys, sortedKeys(got)); diff != "" {
+				t.Errorf("%s\nfilterConsistent(...): -want keys, +got keys:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCaptureJSON(t *testing.T) {
+	msg := `{"error": {"code": 403, "details": [{"message": "access denied", "retryable": false}]}}`
+
+	type args struct {
+		msg   string
+		paths map[string]string
+	}
+	type want struct {
+		groups map[string]string
+		ok     bool
+		err    bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NestedFields": {
+			reason: "Nested string and non-string values should be captured.",
+			args: args{
+				msg:   msg,
+				paths: map[string]string{"Detail": "error.details[0].message", "Code": "error.code", "Retryable": "error.details[0].retryable"},
+			},
+			want: want{groups: map[string]string{"Detail": "access denied", "Code": "403", "Retryable": "false"}, ok: true},
+		},
+		"Object": {
+			reason: "An object value should be captured as JSON.",
+			args: args{
+				msg:   msg,
+				paths: map[string]string{"Detail": "error.details[0]"},
+			},
+			want: want{groups: map[string]string{"Detail": `{"message":"access denied","retryable":false}`}, ok: true},
+		},
+		"MissingField": {
+			reason: "A message without a value at a field path should not match.",
+			args: args{
+				msg:   msg,
+				paths: map[string]string{"Reason": "error.reason"},
+			},
+			want: want{ok: false},
+		},
+		"NotJSON": {
+			reason: "A message that is not a JSON object should not match.",
+			args: args{
+				msg:   "access denied",
+				paths: map[string]string{"Code": "error.code"},
+			},
+			want: want{ok: false},
+		},
+		"InvalidFieldPath": {
+			reason: "An invalid field path should be an error.",
+			args: args{
+				msg:   msg,
+				paths: map[string]string{"Detail": "error.details[0"},
+			},
+			want: want{err: true},
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			f := &Function{log: logging.NewNopLogger()}
-			rsp, err := f.RunFunction(tc.args.ctx, tc.args.req)
+			groups, ok, err := captureJSON(tc.args.msg, tc.args.paths)
+			if gotErr := err != nil; gotErr != tc.want.err {
+				t.Fatalf("%s\ncaptureJSON(...): want error %t, got error %v", tc.reason, tc.want.err, err)
+			}
+			if ok != tc.want.ok {
+				t.Errorf("%s\ncaptureJSON(...): want ok %t, got %t", tc.reason, tc.want.ok, ok)
+			}
+			if diff := cmp.Diff(tc.want.groups, groups); diff != "" {
+				t.Errorf("%s\ncaptureJSON(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
 
-			// The function-sdk-go library depends on the go-json-experiment
-			// library. The go-json-experiment library explicitly randomizes
-			// their error messages between "cannot unmarshal..." and "unable
-			// to unmarshal...". This is to "Hyrum-proof" (see Hyrum's Law)
-			// their library. This means that we must un-Hyrum-proof the strings or
-			// else our unit tests will occasionally fail.
-			if tc.want.cleanError {
-				for i := range rsp.GetConditions() {
-					msg := rsp.GetConditions()[i].GetMessage()
-					if msg == "" {
-						continue
-					}
-					rsp.Conditions[i].Message = ptr.To(strings.ReplaceAll(msg, "unable to unmarshal Go value", "cannot unmarshal Go value"))
-				}
+func TestTruncateMessage(t *testing.T) {
+	type args struct {
+		msg       string
+		maxLength int
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   string
+	}{
+		"ShortMessage": {
+			reason: "A message within the maximum length should not be truncated.",
+			args:   args{msg: "short", maxLength: 10},
+			want:   "short",
+		},
+		"LongMessage": {
+			reason: "A message exceeding the maximum length should be truncated and end with an ellipsis.",
+			args:   args{msg: strings.Repeat("a", 3000), maxLength: defaultMaxMessageLength},
+			want:   strings.Repeat("a", defaultMaxMessageLength-len(ellipsis)) + ellipsis,
+		},
+		"MultiByteCharacters": {
+			reason: "A multi-byte character should not be split when truncating.",
+			args:   args{msg: "ééééé", maxLength: 8},
+			want:   "éé...",
+		},
+		"Disabled": {
+			reason: "A maximum length of zero should disable truncation.",
+			args:   args{msg: "a long message", maxLength: 0},
+			want:   "a long message",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := truncateMessage(tc.args.msg, tc.args.maxLength)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\ntruncateMessage(...): -want, +got:\n%s", tc.reason, diff)
 			}
+			if tc.args.maxLength > 0 && len(got) > tc.args.maxLength {
+				t.Errorf("%s\ntruncateMessage(...): got length %d, want at most %d", tc.reason, len(got), tc.args.maxLength)
+			}
+		})
+	}
+}
 
-			if diff := cmp.Diff(tc.want.rsp, rsp, protocmp.Transform()); diff != "" {
-				t.Errorf("%s\nf.RunFunction(...): -want rsp, +got rsp:\n%s", tc.reason, diff)
+func TestWrapMessage(t *testing.T) {
+	type args struct {
+		msg       string
+		prefix    string
+		suffix    string
+		maxLength int
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   string
+	}{
+		"ShortMessage": {
+			reason: "A prefix and suffix should be added to a message that fits.",
+			args:   args{msg: "message", prefix: "[platform] ", suffix: " (see docs)", maxLength: 100},
+			want:   "[platform] message (see docs)",
+		},
+		"LongMessage": {
+			reason: "The message should be truncated so that the prefix and suffix are kept.",
+			args:   args{msg: "a long message", prefix: "[p] ", suffix: " [s]", maxLength: 16},
+			want:   "[p] a lon... [s]",
+		},
+		"PrefixTooLong": {
+			reason: "The whole message should be truncated if the prefix and suffix don't leave room for it.",
+			args:   args{msg: "message", prefix: "[platform] ", maxLength: 12},
+			want:   "[platform...",
+		},
+		"Disabled": {
+			reason: "A maximum length of zero should disable truncation.",
+			args:   args{msg: "a long message", prefix: "[p] ", maxLength: 0},
+			want:   "[p] a long message",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := wrapMessage(tc.args.msg, tc.args.prefix, tc.args.suffix, tc.args.maxLength)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nwrapMessage(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if tc.args.maxLength > 0 && len(got) > tc.args.maxLength {
+				t.Errorf("%s\nwrapMessage(...): got length %d, want at most %d", tc.reason, len(got), tc.args.maxLength)
+			}
+		})
+	}
+}
+
+func TestPodEnv(t *testing.T) {
+	environ := map[string]string{
+		"REGION":      "us-east-1",
+		"CLUSTER":     "prod",
+		"DB_PASSWORD": "secret",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := environ[name]
+		return v, ok
+	}
+
+	cases := map[string]struct {
+		reason string
+		names  []string
+		want   map[string]string
+	}{
+		"Allowlisted": {
+			reason: "Only allowlisted environment variables should be returned.",
+			names:  []string{"REGION", "CLUSTER"},
+			want:   map[string]string{"REGION": "us-east-1", "CLUSTER": "prod"},
+		},
+		"Unset": {
+			reason: "Allowlisted environment variables that are not set should be omitted.",
+			names:  []string{"REGION", "ZONE"},
+			want:   map[string]string{"REGION": "us-east-1"},
+		},
+		"NoAllowlist": {
+			reason: "No environment variables should be returned without an allowlist.",
+			want:   map[string]string{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := podEnv(tc.names, lookup)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\npodEnv(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+
+	t.Run("Template", func(t *testing.T) {
+		f := &Function{log: logging.NewNopLogger(), podEnv: podEnv([]string{"REGION"}, lookup)}
+		rsp, err := f.RunFunction(context.Background(), &fnv1.RunFunctionRequest{
+			Input: resource.MustStructJSON(`{
+  "apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+  "kind": "StatusTransformation",
+  "statusConditionHooks": [
+    {
+      "matchers": [
+        {
+          "compositeOnly": true,
+          "conditions": [
+            {
+              "type": "Synced",
+              "exists": false
+            }
+          ]
+        }
+      ],
+      "setConditions": [
+        {
+          "condition": {
+            "type": "Checked",
+            "status": "True",
+            "reason": "Checked",
+            "message": "Checked in {{ .PodEnv.REGION }}, password {{ .PodEnv.DB_PASSWORD }}"
+          }
+        }
+      ]
+    }
+  ]
+}`),
+		})
+		if err != nil {
+			t.Fatalf("f.RunFunction(...): unexpected error: %v", err)
+		}
+		want := "Checked in us-east-1, password "
+		if diff := cmp.Diff(want, rsp.GetConditions()[0].GetMessage()); diff != "" {
+			t.Errorf("Allowlisted environment variables should be available to templates as PodEnv.\nf.RunFunction(...): -want message, +got message:\n%s", diff)
+		}
+	})
+}
+
+func TestTemplateMessage(t *testing.T) {
+	type args struct {
+		msg    *string
+		values map[string]any
+	}
+
+	type want struct {
+		msg *string
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NilMessage": {
+			reason: "A nil message should remain nil.",
+			args:   args{msg: nil, values: map[string]any{"Error": "boom"}},
+			want:   want{msg: nil},
+		},
+		"CapturedGroup": {
+			reason: "A captured group should be rendered.",
+			args:   args{msg: ptr.To("failed: {{ .Error }}"), values: map[string]any{"Error": "boom"}},
+			want:   want{msg: ptr.To("failed: boom")},
+		},
+		"NoValues": {
+			reason: "A template should be rendered even without values, so that template syntax doesn't leak into the message.",
+			args:   args{msg: ptr.To("failed: {{ .Error }}"), values: nil},
+			want:   want{msg: ptr.To("failed: ")},
+		},
+		"UncapturedGroup": {
+			reason: "A group that wasn't captured should render as empty text.",
+			args:   args{msg: ptr.To("failed: {{ .Error }}"), values: map[string]any{"Other": "value"}},
+			want:   want{msg: ptr.To("failed: ")},
+		},
+		"MissingNestedValue": {
+			reason: "A missing field of a map value should render as empty text.",
+			args:   args{msg: ptr.To("region: {{ .Env.region }}"), values: map[string]any{"Env": map[string]any{}}},
+			want:   want{msg: ptr.To("region: ")},
+		},
+		"MissingValueInControlStructure": {
+			reason: "A missing value inside an if or range action should render as empty text.",
+			args:   args{msg: ptr.To("{{ if true }}failed: {{ .Error }}{{ end }}{{ range .List }}, {{ .Missing }}{{ end }}"), values: map[string]any{"List": []any{map[string]any{}}}},
+			want:   want{msg: ptr.To("failed: , ")},
+		},
+		"ValueContainingNoValue": {
+			reason: "A value that contains the text <no value> should be rendered as is.",
+			args:   args{msg: ptr.To("failed: {{ .Error }}"), values: map[string]any{"Error": "field was <no value>"}},
+			want:   want{msg: ptr.To("failed: field was <no value>")},
+		},
+		"NoValuesWithoutTemplate": {
+			reason: "A message without template syntax should be rendered as is without values.",
+			args:   args{msg: ptr.To("failed"), values: map[string]any{}},
+			want:   want{msg: ptr.To("failed")},
+		},
+		"InvalidTemplate": {
+			reason: "An invalid template should return an error even without values.",
+			args:   args{msg: ptr.To("failed: {{ .Error"), values: nil},
+			want:   want{err: cmpopts.AnyError},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := templateMessage(tc.args.msg, tc.args.values)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("%s\ntemplateMessage(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.msg, got); diff != "" {
+				t.Errorf("%s\ntemplateMessage(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	object := func(conditions ...any) *composed.Unstructured {
+		return &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "some.example.com/v1alpha1",
+			"kind":       "Object",
+			"status": map[string]any{
+				"conditions": conditions,
+			},
+		}}}
+	}
+	absent := object()
+	unknownEmptyReason := object(map[string]any{"type": "Ready", "status": "Unknown"})
+	unknownWithReason := object(map[string]any{"type": "Ready", "status": "Unknown", "reason": "Initializing"})
+	oversized := object(map[string]any{"type": "Ready", "status": "False", "message": "error: " + strings.Repeat("a", 100) + " END"})
+	emptyMessage := object(map[string]any{"type": "Synced", "status": "True"})
+	withMessage := object(map[string]any{"type": "Synced", "status": "True", "message": "drift detected"})
+	stuck := object(
+		map[string]any{"type": "Ready", "status": "False", "lastTransitionTime": "2024-01-01T10:00:00Z"},
+		map[string]any{"type": "Synced", "status": "True", "lastTransitionTime": "2024-01-01T10:05:00Z"},
+	)
+	recovering := object(
+		map[string]any{"type": "Ready", "status": "False", "lastTransitionTime": "2024-01-01T10:10:00Z"},
+		map[string]any{"type": "Synced", "status": "True", "lastTransitionTime": "2024-01-01T10:05:00Z"},
+	)
+	notSynced := object(
+		map[string]any{"type": "Ready", "status": "False", "lastTransitionTime": "2024-01-01T10:00:00Z"},
+	)
+	readyFalse := func(op v1beta1.TransitionTimeOperator) v1beta1.ConditionMatcher {
+		return v1beta1.ConditionMatcher{
+			Type:           "Ready",
+			Status:         ptr.To(metav1.ConditionFalse),
+			TransitionTime: &v1beta1.TransitionTimeComparison{Operator: op, Type: "Synced"},
+		}
+	}
+
+	unknown := v1beta1.ConditionMatcher{
+		Type:   "Ready",
+		Status: ptr.To(metav1.ConditionUnknown),
+		Reason: ptr.To(""),
+	}
+	exists := func(cm v1beta1.ConditionMatcher, exists bool) v1beta1.ConditionMatcher {
+		cm.Exists = ptr.To(exists)
+		return cm
+	}
+
+	type args struct {
+		cm   v1beta1.ConditionMatcher
+		co   conditionedObject
+		opts matchOptions
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   bool
+	}{
+		"AbsentMatchesUnknown": {
+			reason: "An absent condition should match Unknown with an empty reason.",
+			args:   args{cm: unknown, co: absent},
+			want:   true,
+		},
+		"ProviderUnknownEmptyReasonMatchesUnknown": {
+			reason: "A provider set Unknown condition without a reason should match Unknown with an empty reason.",
+			args:   args{cm: unknown, co: unknownEmptyReason},
+			want:   true,
+		},
+		"ProviderUnknownWithReasonDoesNotMatchUnknown": {
+			reason: "A provider set Unknown condition with a reason should not match Unknown with an empty reason.",
+			args:   args{cm: unknown, co: unknownWithReason},
+			want:   false,
+		},
+		"AbsentDoesNotExist": {
+			reason: "An absent condition should match when the condition must not exist.",
+			args:   args{cm: exists(unknown, false), co: absent},
+			want:   true,
+		},
+		"AbsentDoesNotMatchExists": {
+			reason: "An absent condition should not match when the condition must exist.",
+			args:   args{cm: exists(unknown, true), co: absent},
+			want:   false,
+		},
+		"ProviderUnknownEmptyReasonExists": {
+			reason: "A provider set Unknown condition without a reason should match when the condition must exist.",
+			args:   args{cm: exists(unknown, true), co: unknownEmptyReason},
+			want:   true,
+		},
+		"ProviderUnknownEmptyReasonDoesNotMatchAbsent": {
+			reason: "A provider set Unknown condition without a reason should not match when the condition must not exist.",
+			args:   args{cm: exists(unknown, false), co: unknownEmptyReason},
+			want:   false,
+		},
+		"ProviderUnknownWithReasonExists": {
+			reason: "A provider set Unknown condition with a reason should match its reason when the condition must exist.",
+			args:   args{cm: exists(v1beta1.ConditionMatcher{Type: "Ready", Status: ptr.To(metav1.ConditionUnknown), Reason: ptr.To("Initializing")}, true), co: unknownWithReason},
+			want:   true,
+		},
+		"OversizedMessageTruncated": {
+			reason: "A message longer than the maximum matched message length should be truncated before matching.",
+			args: args{
+				cm:   v1beta1.ConditionMatcher{Type: "Ready", Message: ptr.To("END$")},
+				co:   oversized,
+				opts: matchOptions{maxMatchedMessageLength: 50},
+			},
+			want: false,
+		},
+		"OversizedMessageNotTruncated": {
+			reason: "A message should not be truncated before matching when there is no maximum matched message length.",
+			args: args{
+				cm: v1beta1.ConditionMatcher{Type: "Ready", Message: ptr.To("END$")},
+				co: oversized,
+			},
+			want: true,
+		},
+		"EmptyMessageRegexMatchesEmpty": {
+			reason: "A message regular expression of ^$ should match an empty message.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", Message: ptr.To("^$")}, co: emptyMessage},
+			want:   true,
+		},
+		"EmptyMessageRegexDoesNotMatchMessage": {
+			reason: "A message regular expression of ^$ should not match a message.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", Message: ptr.To("^$")}, co: withMessage},
+			want:   false,
+		},
+		"UnanchoredMessageMatchesPart": {
+			reason: "An unanchored message regular expression should match part of the message.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", Message: ptr.To("drift")}, co: withMessage},
+			want:   true,
+		},
+		"AnchoredMessageDoesNotMatchPart": {
+			reason: "An anchored message regular expression should not match part of the message.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", Message: ptr.To("drift"), MessageAnchored: ptr.To(true)}, co: withMessage},
+			want:   false,
+		},
+		"AnchoredMessageMatchesWhole": {
+			reason: "An anchored message regular expression should match the whole message.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", Message: ptr.To("drift (detected|resolved)"), MessageAnchored: ptr.To(true)}, co: withMessage},
+			want:   true,
+		},
+		"EmptyMessageMatchesEmpty": {
+			reason: "An empty message should match when the message must be empty.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", EmptyMessage: ptr.To(true)}, co: emptyMessage},
+			want:   true,
+		},
+		"EmptyMessageDoesNotMatchMessage": {
+			reason: "A message should not match when the message must be empty.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", EmptyMessage: ptr.To(true)}, co: withMessage},
+			want:   false,
+		},
+		"NotEmptyMessageMatchesMessage": {
+			reason: "A message should match when the message must not be empty.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", EmptyMessage: ptr.To(false)}, co: withMessage},
+			want:   true,
+		},
+		"NotEmptyMessageDoesNotMatchEmpty": {
+			reason: "An empty message should not match when the message must not be empty.",
+			args:   args{cm: v1beta1.ConditionMatcher{Type: "Synced", EmptyMessage: ptr.To(false)}, co: emptyMessage},
+			want:   false,
+		},
+		"TransitionedBeforeOtherCondition": {
+			reason: "A condition that transitioned before the other condition should match Before.",
+			args:   args{cm: readyFalse(v1beta1.TransitionedBefore), co: stuck},
+			want:   true,
+		},
+		"TransitionedAfterOtherCondition": {
+			reason: "A condition that transitioned after the other condition should not match Before.",
+			args:   args{cm: readyFalse(v1beta1.TransitionedBefore), co: recovering},
+			want:   false,
+		},
+		"TransitionedAfter": {
+			reason: "A condition that transitioned after the other condition should match After.",
+			args:   args{cm: readyFalse(v1beta1.TransitionedAfter), co: recovering},
+			want:   true,
+		},
+		"TransitionTimeOtherConditionAbsent": {
+			reason: "A condition should not match a transition time comparison when the other condition is absent.",
+			args:   args{cm: readyFalse(v1beta1.TransitionedBefore), co: notSynced},
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), logKey, logging.NewNopLogger())
+			got, _, err := match(ctx, tc.args.cm, tc.args.co, tc.args.opts)
+			if err != nil {
+				t.Fatalf("%s\nmatch(...): unexpected error: %v", tc.reason, err)
 			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nmatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMatchMessageAnyOf(t *testing.T) {
+	co := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "some.example.com/v1alpha1",
+		"kind":       "Object",
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": "Synced", "status": "False", "message": "quota exceeded in region us-east-1"},
+			},
+		},
+	}}}
+
+	type want struct {
+		matched bool
+		groups  map[string]string
+		err     error
+	}
 
+	cases := map[string]struct {
+		reason string
+		cm     v1beta1.ConditionMatcher
+		want   want
+	}{
+		"SecondPatternMatches": {
+			reason: "The captures of the pattern that matched should be returned.",
+			cm: v1beta1.ConditionMatcher{Type: "Synced", MessageAnyOf: []string{
+				"permission denied for (?P<Principal>.+)",
+				"quota exceeded in region (?P<Region>.+)",
+			}},
+			want: want{matched: true, groups: map[string]string{"Region": "us-east-1"}},
+		},
+		"FirstMatchingPatternWins": {
+			reason: "Only the captures of the first pattern that matched should be returned.",
+			cm: v1beta1.ConditionMatcher{Type: "Synced", MessageAnyOf: []string{
+				"quota exceeded in (?P<Where>.+)",
+				"quota exceeded in region (?P<Region>.+)",
+			}},
+			want: want{matched: true, groups: map[string]string{"Where": "region us-east-1"}},
+		},
+		"NoPatternMatches": {
+			reason: "The condition should not match if no pattern matches.",
+			cm:     v1beta1.ConditionMatcher{Type: "Synced", MessageAnyOf: []string{"permission denied", "not found"}},
+			want:   want{matched: false},
+		},
+		"Anchored": {
+			reason: "Anchored patterns should match the whole message.",
+			cm:     v1beta1.ConditionMatcher{Type: "Synced", MessageAnyOf: []string{"quota exceeded", "quota exceeded in region .+"}, MessageAnchored: ptr.To(true)},
+			want:   want{matched: true, groups: map[string]string{}},
+		},
+		"WithMessage": {
+			reason: "Both Message and one of MessageAnyOf should have to match.",
+			cm:     v1beta1.ConditionMatcher{Type: "Synced", Message: ptr.To("(?P<Error>quota exceeded)"), MessageAnyOf: []string{"region (?P<Region>.+)"}},
+			want:   want{matched: true, groups: map[string]string{"Error": "quota exceeded", "Region": "us-east-1"}},
+		},
+		"InvalidPattern": {
+			reason: "An invalid pattern should return an error.",
+			cm:     v1beta1.ConditionMatcher{Type: "Synced", MessageAnyOf: []string{"("}},
+			want:   want{err: cmpopts.AnyError},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), logKey, logging.NewNopLogger())
+			matched, groups, err := match(ctx, tc.cm, co, matchOptions{})
 			if diff := cmp.Diff(tc.want.err, err, cmpopts.EquateErrors()); diff != "" {
-				t.Errorf("%s\nf.RunFunction(...): -want err, +got err:\n%s", tc.reason, diff)
+				t.Errorf("%s\nmatch(...): -want err, +got err:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.matched, matched); diff != "" {
+				t.Errorf("%s\nmatch(...): -want matched, +got matched:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.groups, groups, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nmatch(...): -want groups, +got groups:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
+
+// panickingLogger panics when a run adds values to it.
+type panickingLogger struct {
+	logging.Logger
+}
+
+func (l panickingLogger) WithValues(_ ...any) logging.Logger {
+	panic("boom")
+}
+
+func TestHealth(t *testing.T) {
+	req := &fnv1.RunFunctionRequest{
+		Input: resource.MustStructJSON(`{
+			"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+			"kind": "StatusTransformation"
+		}`),
+	}
+	probe := func(f *Function) int {
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return w.Code
+	}
+
+	f := &Function{log: logging.NewNopLogger()}
+	if got := probe(f); got != http.StatusOK {
+		t.Errorf("before any run: probe: want %d, got %d", http.StatusOK, got)
+	}
+
+	run := func(f *Function, n int) {
+		for range n {
+			if _, err := f.RunFunction(context.Background(), req); err != nil {
+				t.Errorf("RunFunction(...): unexpected error: %v", err)
+			}
+		}
+	}
+
+	f.log = panickingLogger{Logger: logging.NewNopLogger()}
+	run(f, 1)
+	if got := probe(f); got != http.StatusOK {
+		t.Errorf("after one panicking run: probe: want %d, got %d", http.StatusOK, got)
+	}
+
+	run(f, healthPanicThreshold-1)
+	if got := probe(f); got != http.StatusServiceUnavailable {
+		t.Errorf("after %d panicking runs: probe: want %d, got %d", healthPanicThreshold, http.StatusServiceUnavailable, got)
+	}
+
+	f.log = logging.NewNopLogger()
+	run(f, healthWindow-healthPanicThreshold)
+	if got := probe(f); got != http.StatusServiceUnavailable {
+		t.Errorf("while panicking runs are within the window: probe: want %d, got %d", http.StatusServiceUnavailable, got)
+	}
+
+	run(f, 1)
+	if got := probe(f); got != http.StatusOK {
+		t.Errorf("after the oldest panicking run left the window: probe: want %d, got %d", http.StatusOK, got)
+	}
+}
+
+func TestRunFunctionRecoversFromPanic(t *testing.T) {
+	req := &fnv1.RunFunctionRequest{
+		Meta: &fnv1.RequestMeta{Tag: "hello"},
+		Input: resource.MustStructJSON(`{
+			"apiVersion": "function-status-transformer.fn.crossplane.io/v1beta1",
+			"kind": "StatusTransformation"
+		}`),
+	}
+	want := &fnv1.RunFunctionResponse{
+		Meta:    &fnv1.ResponseMeta{Tag: "hello", Ttl: durationpb.New(response.DefaultTTL)},
+		Results: []*fnv1.Result{},
+		Conditions: []*fnv1.Condition{
+			{
+				Type:    "StatusTransformationSuccess",
+				Status:  fnv1.Status_STATUS_CONDITION_FALSE,
+				Reason:  "InternalError",
+				Target:  fnv1.Target_TARGET_COMPOSITE.Enum(),
+				Message: ptr.To("recovered from panic: boom"),
+			},
+		},
+	}
+
+	f := &Function{log: panickingLogger{Logger: logging.NewNopLogger()}}
+	rsp, err := f.RunFunction(context.Background(), req)
+	if err != nil {
+		t.Errorf("RunFunction(...): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, rsp, protocmp.Transform()); diff != "" {
+		t.Errorf("RunFunction(...): -want rsp, +got rsp:\n%s", diff)
+	}
+}
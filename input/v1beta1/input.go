@@ -17,9 +17,320 @@ type StatusTransformation struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	StatusConditionHooks []StatusConditionHook `json:"statusConditionHooks"`
+
+	// NamedMatchers are groups of matchers that hooks can reference by name
+	// with MatcherRefs, so that matchers shared by many hooks are only defined
+	// once. Optional.
+	// +optional
+	NamedMatchers map[string][]Matcher `json:"namedMatchers"`
+
+	// NamedConditions are sets of condition matchers that matchers can
+	// reference by name with ConditionRefs, so that conditions shared by many
+	// matchers are only defined once. Optional.
+	// +optional
+	NamedConditions map[string][]ConditionMatcher `json:"namedConditions"`
+
+	// ReadinessRollup sets a single condition from the readiness of many
+	// resources. Optional. It is evaluated after the hooks, which take
+	// precedence when they set a condition of the same type.
+	// +optional
+	ReadinessRollup *ReadinessRollup `json:"readinessRollup"`
+
+	// WhenAllHooksEvaluated are evaluated in order after the hooks and the
+	// readiness rollup. They set conditions and create events based on which
+	// conditions were set, e.g. to set Healthy when no hook set Degraded.
+	// Optional.
+	// +optional
+	WhenAllHooksEvaluated []AggregateHook `json:"whenAllHooksEvaluated"`
+
+	// ResourceSelector limits the observed resources considered by all hooks.
+	// Optional. Resources that are not selected are never matched. The
+	// composite resource and extra resources are not affected.
+	// +optional
+	ResourceSelector *ResourceSelector `json:"resourceSelector"`
+
+	// LogLevel adjusts the verbosity of the logs emitted while processing this
+	// input. Optional. Can be Info or Debug. Info suppresses debug logs, while
+	// Debug emits them at the info level so they are visible even when the
+	// function is not running with debug logging enabled. If omitted, the
+	// function's own log level is used.
+	// +optional
+	LogLevel *LogLevel `json:"logLevel"`
+
+	// EnvironmentContextKey is the function context key to read the environment
+	// from. Optional. Defaults to apiextensions.crossplane.io/environment. The
+	// environment is available to templates under the Env key, for example
+	// {{ .Env.region }}. The environment must be an object. If the key is set
+	// and does not hold one, the function fails.
+	// +optional
+	EnvironmentContextKey *string `json:"environmentContextKey"`
+
+	// HooksContextKey is a function context key to read additional
+	// statusConditionHooks from, e.g. a shared library of hooks loaded by an
+	// earlier function. The hooks are appended to StatusConditionHooks, so
+	// the hooks of the input are evaluated first. Optional.
+	// +optional
+	HooksContextKey *string `json:"hooksContextKey"`
+
+	// OnGroupConflict determines what happens when capture groups of the same
+	// name capture different values, for example because two matchers both
+	// capture a group named Code. Optional. Can be Overwrite, Error, or Keep.
+	// Overwrite keeps the value captured last, Keep keeps the value captured
+	// first, and Error fails to match. With Error, a conflict is reported like
+	// any other matcher failure, so it only skips the hook if StrictMatching
+	// is false. Defaults to Overwrite.
+	// +optional
+	OnGroupConflict *GroupConflictPolicy `json:"onGroupConflict"`
+
+	// EmitErrorEvents creates a Warning event describing each failure
+	// encountered while evaluating the hooks, in addition to setting the
+	// StatusTransformationSuccess condition. Optional. Defaults to false.
+	// +optional
+	EmitErrorEvents *bool `json:"emitErrorEvents"`
+
+	// StrictMatching reports matcher failures, such as an invalid regular
+	// expression, by setting the StatusTransformationSuccess condition to
+	// False. If false, such failures, including conflicting capture groups,
+	// are logged and treated as not matched.
+	// Optional. Defaults to true.
+	// +optional
+	StrictMatching *bool `json:"strictMatching"`
+
+	// EmitSuccessCondition sets the StatusTransformationSuccess condition to
+	// True when all hooks were evaluated successfully. Failures are always
+	// reported. Optional. Defaults to true.
+	// +optional
+	EmitSuccessCondition *bool `json:"emitSuccessCondition"`
+
+	// ReportNoMatch sets the reason of a True StatusTransformationSuccess
+	// condition to NoMatch rather than Available when no hook set a condition,
+	// to tell runs where nothing matched apart. Optional. Defaults to false.
+	// +optional
+	ReportNoMatch *bool `json:"reportNoMatch"`
+
+	// WarnOnEmpty emits a Warning result and sets the reason of a True
+	// StatusTransformationSuccess condition to NoHooks when the input has no
+	// statusConditionHooks, including those read from HooksContextKey, no
+	// whenAllHooksEvaluated, and no readinessRollup, which is usually a
+	// misconfiguration. Optional. Defaults to false.
+	// +optional
+	WarnOnEmpty *bool `json:"warnOnEmpty"`
+
+	// EmitSuccessEvent creates a Normal event when all hooks were evaluated
+	// successfully, e.g. to confirm that the function ran. Optional. Defaults
+	// to false.
+	// +optional
+	EmitSuccessEvent *bool `json:"emitSuccessEvent"`
+
+	// SuccessEventMessage is the message of the event created by
+	// EmitSuccessEvent. Optional. A template can be used. The composite
+	// resource and the environment are available to it. Defaults to "Status
+	// conditions were evaluated successfully".
+	// +optional
+	SuccessEventMessage *string `json:"successEventMessage"`
+
+	// SuccessConditionTarget is the target of the StatusTransformationSuccess
+	// condition, e.g. CompositeAndClaim to show the health of the function to
+	// claim consumers. Optional. Defaults to Composite. Failures to parse the
+	// input and internal errors are always reported on the composite resource.
+	// +optional
+	SuccessConditionTarget *Target `json:"successConditionTarget"`
+
+	// MaxMessageLength is the maximum length in bytes of a rendered condition
+	// or event message. Optional. Longer messages are truncated and end with an
+	// ellipsis. Can be overridden per condition and event. A value of 0
+	// disables truncation. Defaults to 2048.
+	// +optional
+	MaxMessageLength *int `json:"maxMessageLength"`
+
+	// MessagePrefix is prepended to the rendered message of every condition
+	// that a hook sets from a template, e.g. "[platform] " to show where the
+	// condition came from. Conditions without a message and conditions copied
+	// with CopyCondition are left as is. Optional. It is kept when the message
+	// is truncated.
+	// +optional
+	MessagePrefix *string `json:"messagePrefix"`
+
+	// MessageSuffix is appended to the rendered message of every condition
+	// that a hook sets from a template. Like MessagePrefix, it is not added to
+	// conditions without a message or to copied conditions. Optional. It is
+	// kept when the message is truncated.
+	// +optional
+	MessageSuffix *string `json:"messageSuffix"`
+
+	// MaxEvents is the maximum number of events created by the hooks.
+	// Optional. Once it is reached, further events are dropped and a single
+	// Warning result reports how many. Defaults to no limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxEvents *int `json:"maxEvents"`
+
+	// StatusToSeverity maps the status of a matched condition to the type of
+	// the events a hook creates without an explicit type. Optional. If
+	// omitted or empty, such events are Normal. Otherwise its entries
+	// override the defaults, which map False to Warning, and True and Unknown
+	// to Normal. Keys are parsed like condition statuses, and a key that is
+	// not a status is an error. An event is created with the first type other
+	// than Normal that a matched condition maps to, and is Normal otherwise.
+	// +optional
+	StatusToSeverity map[string]EventType `json:"statusToSeverity"`
+
+	// MaxMatchedMessageLength is the maximum length in bytes of a condition
+	// message that is matched against a message regular expression. Optional.
+	// Longer messages are truncated before matching, which protects the
+	// function from very large messages. A value of 0 disables truncation.
+	// Defaults to 16384.
+	// +optional
+	MaxMatchedMessageLength *int `json:"maxMatchedMessageLength"`
+
+	// RespectDesiredConditions treats conditions already present on the
+	// desired composite resource, e.g. set by earlier functions in the
+	// pipeline, as already set. Non-forceful setConditions will not override
+	// them. Optional. Defaults to false.
+	// +optional
+	RespectDesiredConditions *bool `json:"respectDesiredConditions"`
+
+	// SortConditions sorts the conditions set by the function by type, and
+	// then by target, instead of the order in which they were set. The
+	// StatusTransformationSuccess condition is always last. Optional.
+	// Defaults to false.
+	// +optional
+	SortConditions *bool `json:"sortConditions"`
+
+	// ValidateConditionFormat validates that the type and reason of each
+	// condition set by a hook, and the reason of each event it creates,
+	// follow the Kubernetes conventions, e.g. Ready and ReconcileSuccess. A
+	// condition or event that does not is not set or created, and
+	// StatusTransformationSuccess is set to False with a reason of
+	// SetConditionFailure. Optional. Defaults to false.
+	// +optional
+	ValidateConditionFormat *bool `json:"validateConditionFormat"`
+}
+
+// ReadinessRollup sets a condition that is True when all of the selected
+// resources are ready, and False with a message listing the unready resources
+// otherwise.
+type ReadinessRollup struct {
+	// Resources to roll up. Optional. Defaults to all observed resources.
+	// +optional
+	Resources []ResourceMatcher `json:"resources"`
+
+	// ConditionTypes that must be True for a resource to be ready. Optional.
+	// Defaults to Synced and Ready.
+	// +optional
+	ConditionTypes []string `json:"conditionTypes"`
+
+	// Type of the condition to set. Optional. Defaults to Ready.
+	// +optional
+	Type *string `json:"type"`
+
+	// Target of the condition. Optional. Defaults to Composite.
+	// +optional
+	Target *Target `json:"target"`
+
+	// UnknownWhileCreating sets the condition to Unknown, rather than False,
+	// when no resource is failing but some are still being created. A
+	// resource is failing if any of its ConditionTypes is False with a reason
+	// other than one of the CreatingReasons. Any other resource that is not
+	// ready is still being created. Optional. Defaults to false.
+	// +optional
+	UnknownWhileCreating *bool `json:"unknownWhileCreating"`
+
+	// CreatingReasons are the reasons of a False condition that mark a
+	// resource as still being created rather than failing. Optional. Only
+	// used with UnknownWhileCreating. Defaults to Creating.
+	// +optional
+	CreatingReasons []string `json:"creatingReasons"`
+}
+
+// ResourceSelector selects observed resources. A resource must match all of
+// the supplied criteria to be selected.
+type ResourceSelector struct {
+	// Name is a regular expression that must match the key of the resource in
+	// the observed resource map. Optional.
+	// +optional
+	Name *string `json:"name"`
+
+	// MatchLabels are labels the resource must have. Optional.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels"`
+
+	// MatchAnnotations are annotations the resource must have. Optional.
+	// +optional
+	MatchAnnotations map[string]string `json:"matchAnnotations"`
+}
+
+// ConsistentConditions requires conditions of a resource to agree.
+type ConsistentConditions struct {
+	// Types of the conditions that must all have the same status. Required.
+	// A resource that is missing any of them is not consistent.
+	// +kubebuilder:validation:MinItems=1
+	Types []string `json:"types"`
+
+	// Status the conditions must all have. Optional. The same aliases as
+	// Condition Status are accepted. If omitted, the conditions may share any
+	// status.
+	// +optional
+	Status *metav1.ConditionStatus `json:"status"`
 }
 
-// Target determines which objects to set the condition on.
+// OwnerReferenceMatcher matches an owner reference of a resource. All of the
+// supplied fields must match.
+type OwnerReferenceMatcher struct {
+	// APIVersion is a regular expression that must match the apiVersion of
+	// the owner, e.g. ^example\.org/. Optional.
+	// +optional
+	APIVersion *string `json:"apiVersion"`
+
+	// Kind is a regular expression that must match the kind of the owner,
+	// e.g. ^XNetwork$. Optional.
+	// +optional
+	Kind *string `json:"kind"`
+
+	// Name is a regular expression that must match the name of the owner.
+	// Optional.
+	// +optional
+	Name *string `json:"name"`
+
+	// Controller matches owner references based on whether the owner is the
+	// resource's controller. Optional.
+	// +optional
+	Controller *bool `json:"controller"`
+}
+
+// +kubebuilder:validation:Enum=Overwrite;Error;Keep
+
+// GroupConflictPolicy determines how conflicting capture groups are handled.
+type GroupConflictPolicy string
+
+const (
+	// GroupConflictOverwrite keeps the value captured last.
+	GroupConflictOverwrite GroupConflictPolicy = "Overwrite"
+
+	// GroupConflictError fails to match.
+	GroupConflictError GroupConflictPolicy = "Error"
+
+	// GroupConflictKeep keeps the value captured first.
+	GroupConflictKeep GroupConflictPolicy = "Keep"
+)
+
+// +kubebuilder:validation:Enum=Info;Debug
+
+// LogLevel determines which messages are logged.
+type LogLevel string
+
+const (
+	// LogLevelInfo only logs info messages.
+	LogLevelInfo LogLevel = "Info"
+
+	// LogLevelDebug logs both info and debug messages.
+	LogLevelDebug LogLevel = "Debug"
+)
+
+// +kubebuilder:validation:Enum=Composite;CompositeAndClaim
+
+// Target determines which objects to set the condition on. Conditions can only
+// be set on the composite resource and the claim, not on composed resources.
 type Target string
 
 const (
@@ -47,18 +358,138 @@ const (
 
 	// AllResourcesMatchAllConditions - All resources must match all condition.
 	AllResourcesMatchAllConditions MatchType = "AllResourcesMatchAllConditions"
+
+	// ResourceMatchesAllConditions - A single resource must match all
+	// conditions. Selecting more than one resource is an error.
+	ResourceMatchesAllConditions MatchType = "ResourceMatchesAllConditions"
+
+	// CompareStatusCounts - The number of resources with a condition status
+	// must compare to the number of resources with another status. Conditions
+	// are ignored in favor of StatusCounts.
+	CompareStatusCounts MatchType = "CompareStatusCounts"
+
+	// CompareResourceCount - The number of selected resources must compare to
+	// a count. Conditions are ignored in favor of ResourceCount.
+	CompareResourceCount MatchType = "CompareResourceCount"
+)
+
+// +kubebuilder:validation:Enum=GreaterThan;GreaterThanOrEqual;LessThan;LessThanOrEqual;Equal;NotEqual
+
+// ComparisonOperator compares two counts.
+type ComparisonOperator string
+
+const (
+	// ComparisonGreaterThan - The count must be greater than the other count.
+	ComparisonGreaterThan ComparisonOperator = "GreaterThan"
+
+	// ComparisonGreaterThanOrEqual - The count must be greater than or equal
+	// to the other count.
+	ComparisonGreaterThanOrEqual ComparisonOperator = "GreaterThanOrEqual"
+
+	// ComparisonLessThan - The count must be less than the other count.
+	ComparisonLessThan ComparisonOperator = "LessThan"
+
+	// ComparisonLessThanOrEqual - The count must be less than or equal to the
+	// other count.
+	ComparisonLessThanOrEqual ComparisonOperator = "LessThanOrEqual"
+
+	// ComparisonEqual - The count must equal the other count.
+	ComparisonEqual ComparisonOperator = "Equal"
+
+	// ComparisonNotEqual - The count must not equal the other count.
+	ComparisonNotEqual ComparisonOperator = "NotEqual"
 )
 
+// StatusCountComparison compares the number of resources whose condition has
+// Status to the number of resources whose condition has OtherStatus.
+type StatusCountComparison struct {
+	// Type of the condition to count. Required.
+	Type string `json:"type"`
+
+	// Status to count. Required. The same aliases as Condition Status are
+	// accepted.
+	Status metav1.ConditionStatus `json:"status"`
+
+	// Operator used to compare the count of Status to the count of
+	// OtherStatus. Optional. Defaults to GreaterThan.
+	// +optional
+	Operator *ComparisonOperator `json:"operator"`
+
+	// OtherStatus to count. Required. The same aliases as Condition Status
+	// are accepted.
+	OtherStatus metav1.ConditionStatus `json:"otherStatus"`
+}
+
+// ResourceCountComparison compares the number of selected resources to a
+// count, regardless of their conditions.
+type ResourceCountComparison struct {
+	// Operator used to compare the number of selected resources to Count.
+	// Optional. Defaults to Equal.
+	// +optional
+	Operator *ComparisonOperator `json:"operator"`
+
+	// Count to compare the number of selected resources to. Required.
+	// +kubebuilder:validation:Minimum=0
+	Count int `json:"count"`
+}
+
 // SetCondition will set a condition on the target.
 type SetCondition struct {
 	// The target(s) to receive the condition. Can be Composite or
 	// CompositeAndClaim.
 	Target *Target `json:"target"`
-	// If true, the condition will override a condition of the same Type. Defaults
-	// to false.
+	// If true, the condition will override a condition of the same Type and
+	// Target. Defaults to false.
 	Force *bool `json:"force"`
-	// Condition to set.
+	// Condition to set. Required unless CopyCondition is set.
+	// +optional
 	Condition Condition `json:"condition"`
+	// CopyCondition copies a condition from a matched resource, instead of
+	// setting Condition. Optional.
+	// +optional
+	CopyCondition *CopyCondition `json:"copyCondition"`
+	// AggregateMessages renders the condition's message once for each matched
+	// resource and joins the rendered messages. The matched resource, including
+	// the groups captured from it, is available to the template as Resource,
+	// e.g. {{ .Resource.Key }}: {{ .Resource.Groups.error }}. Optional.
+	// +optional
+	AggregateMessages *MessageAggregation `json:"aggregateMessages"`
+	// If true, the message of an existing composite condition of the same Type
+	// is kept when only the message changed. Crossplane updates the
+	// lastTransitionTime of a condition whenever its message changes, so this
+	// keeps the lastTransitionTime stable for messages that change every
+	// reconcile. Defaults to false.
+	// +optional
+	PreserveTransitionTime *bool `json:"preserveTransitionTime"`
+	// Preconditions the observed composite resource's own conditions must all
+	// match for the condition to be set, e.g. to only set a condition once
+	// the composite resource is Synced. Optional. Capture groups of
+	// preconditions are not available to templates.
+	// +optional
+	Preconditions []ConditionMatcher `json:"preconditions"`
+}
+
+// MessageAggregation configures how the messages rendered for each matched
+// resource are joined.
+type MessageAggregation struct {
+	// Separator placed between messages. Optional. Defaults to "; ".
+	// +optional
+	Separator *string `json:"separator"`
+	// Distinct drops rendered messages that were already rendered for an
+	// earlier resource, e.g. to list each distinct reason once. Optional.
+	// Defaults to false.
+	// +optional
+	Distinct *bool `json:"distinct"`
+}
+
+// CopyCondition copies the status, reason, and message of a condition from
+// the first matched resource that has it.
+type CopyCondition struct {
+	// Type of the condition to copy. Required.
+	Type string `json:"type"`
+	// As renames the copied condition. Optional. Defaults to Type.
+	// +optional
+	As *string `json:"as"`
 }
 
 // Condition allows you to specify fields to set on a composite resource and
@@ -66,7 +497,9 @@ type SetCondition struct {
 type Condition struct {
 	// Type of the condition. Required.
 	Type string `json:"type"`
-	// Status of the condition. Required.
+	// Status of the condition. Required. Can be True, False, or Unknown. The
+	// aliases true, yes, ok, false, and no are also accepted, regardless of
+	// case.
 	Status metav1.ConditionStatus `json:"status"`
 	// Reason of the condition. Required.
 	Reason string `json:"reason"`
@@ -74,11 +507,17 @@ type Condition struct {
 	// template variables come from capturing groups in MatchCondition message
 	// regular expressions.
 	Message *string `json:"message"`
+	// MaxMessageLength overrides the maximum length in bytes of the rendered
+	// message. Optional.
+	// +optional
+	MaxMessageLength *int `json:"maxMessageLength"`
 }
 
 // Matcher will attempt to match a condition on the resource.
 type Matcher struct {
-	// Name of the matcher. Optional. Will be used in logging.
+	// Name of the matcher. Optional. Will be used in logging. The capture
+	// groups of a named matcher are also available to templates under its
+	// name, e.g. {{ .db.Error }}.
 	Name *string `json:"name"`
 
 	// Type will determine the behavior of the match. Can be one of the following.
@@ -86,48 +525,481 @@ type Matcher struct {
 	// AnyResourceMatchesAllConditions - Any resource must match all conditions.
 	// AllResourcesMatchAnyCondition - All resources must match any condition.
 	// AllResourcesMatchAllConditions - All resources must match all condition.
+	// ResourceMatchesAllConditions - A single resource must match all conditions.
+	// CompareStatusCounts - Compare the number of resources with two condition
+	// statuses. Requires StatusCounts.
+	// CompareResourceCount - Compare the number of selected resources to a
+	// count. Requires ResourceCount.
 	Type *MatchType `json:"type"`
 
+	// StatusCounts to compare when Type is CompareStatusCounts.
+	// +optional
+	StatusCounts *StatusCountComparison `json:"statusCounts"`
+
+	// ResourceCount to compare when Type is CompareResourceCount.
+	// +optional
+	ResourceCount *ResourceCountComparison `json:"resourceCount"`
+
 	// Resources that should have their conditions matched against.
 	Resources []ResourceMatcher `json:"resources"`
 
 	// Conditions that must exist on the resource(s).
 	Conditions []ConditionMatcher `json:"conditions"`
 
+	// ConditionRefs are the names of NamedConditions sets to match in
+	// addition to Conditions. Optional. The referenced conditions are
+	// evaluated in order before Conditions.
+	// +optional
+	ConditionRefs []string `json:"conditionRefs"`
+
 	// IncludeCompositeAsResource allows you to add the Composite Resource to the
 	// list of resources.
 	IncludeCompositeAsResource *bool `json:"includeCompositeAsResource"`
+
+	// CompositeOnly limits the list of resources to the Composite Resource.
+	// Resources, IncludeCompositeAsResource, and IncludeExtraResources are
+	// ignored. Cannot be used with ExtraResourcesOnly.
+	// +optional
+	CompositeOnly *bool `json:"compositeOnly"`
+
+	// IncludeExtraResources allows you to add the extra resources supplied to
+	// the function to the list of resources. Extra resources are merged with the
+	// other resources and are evaluated using the same Type.
+	// +optional
+	IncludeExtraResources *bool `json:"includeExtraResources"`
+
+	// ExtraResources selects extra resources. Optional. Each name is matched
+	// against the name the extra resource was requested under, followed by
+	// either its index or its metadata.name, e.g. "buckets.0" or
+	// "buckets.bucket-a". The metadata.name of a namespaced extra resource is
+	// prefixed by its namespace, e.g. "buckets.team-a/bucket-a". Selected
+	// extra resources are merged with the other resources. When used with
+	// ExtraResourcesOnly, only the selected extra resources are matched
+	// against. If omitted, IncludeExtraResources and ExtraResourcesOnly select
+	// all extra resources.
+	// +optional
+	ExtraResources []ResourceMatcher `json:"extraResources"`
+
+	// ExtraResourcesOnly limits the list of resources to the extra resources
+	// supplied to the function. Resources and IncludeCompositeAsResource are
+	// ignored. Cannot be used with CompositeOnly. Use it to require, e.g.,
+	// that all extra resources are ready without also requiring it of the
+	// composed resources.
+	// +optional
+	ExtraResourcesOnly *bool `json:"extraResourcesOnly"`
+
+	// Absent matches when Resources select no observed resources, e.g. to
+	// match a resource that does not exist yet. Optional. Conditions are
+	// ignored. Combined with other matchers of a hook, this matches the
+	// absence of one resource together with the conditions of another.
+	// Defaults to false.
+	// +optional
+	Absent *bool `json:"absent"`
+
+	// ConditionsPath is the field path of the conditions of the selected
+	// resources, for resources that don't report them at status.conditions,
+	// e.g. status.health.conditions. Optional. The conditions must have the
+	// same fields as standard conditions. A resource without conditions at the
+	// path is treated as having no conditions.
+	// +optional
+	ConditionsPath *string `json:"conditionsPath"`
+
+	// ConversionFailed matches the resources selected by Resources that could
+	// not be converted to objects, e.g. to surface a condition about invalid
+	// resource data. Optional. The matcher matches if any selected resource
+	// failed to convert, and Conditions are ignored. Without it, selecting a
+	// resource that failed to convert is an error. Defaults to false.
+	// +optional
+	ConversionFailed *bool `json:"conversionFailed"`
+
+	// MatchOwnerReferences limits the selected resources to those with at
+	// least one owner reference matched by any of the supplied matchers.
+	// Optional. An owner reference matches a matcher if it matches each of the
+	// matcher's fields that are set. Resources without owner references are
+	// never selected.
+	// +optional
+	MatchOwnerReferences []OwnerReferenceMatcher `json:"matchOwnerReferences"`
+
+	// MatchLabels limits the selected resources to those with all of the
+	// supplied labels. Optional.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels"`
+
+	// MatchAnnotations limits the selected resources to those with all of the
+	// supplied annotations. Optional. Values are compared exactly, not as
+	// regular expressions. A resource without one of the annotations is not
+	// selected, even if the supplied value is empty.
+	// +optional
+	MatchAnnotations map[string]string `json:"matchAnnotations"`
+
+	// MinMatches is the minimum number of resources that must match for the
+	// matcher to match. Optional. When MinMatches or MaxMatches is set, the
+	// Type only determines whether a resource must match any or all
+	// conditions, and the matcher matches when the number of matching
+	// resources is within the inclusive range.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinMatches *int `json:"minMatches"`
+
+	// MaxMatches is the maximum number of resources that may match for the
+	// matcher to match. Optional. See MinMatches.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxMatches *int `json:"maxMatches"`
+
+	// MinMatchesPercent is the minimum percentage of the selected resources
+	// that must match for the matcher to match. Optional. It is evaluated in
+	// the same way as MinMatches, and can be combined with it. It is a whole
+	// percentage, compared against the exact percentage without rounding, e.g.
+	// 1 of 3 resources is 33.3%, which is at least 33 but not at least 34. The
+	// percentage is available to templates under the MatchesPercent key.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MinMatchesPercent *int `json:"minMatchesPercent"`
+
+	// MaxMatchesPercent is the maximum percentage of the selected resources
+	// that may match for the matcher to match. Optional. Like
+	// MinMatchesPercent, it is a whole percentage compared against the exact
+	// percentage, e.g. 2 of 3 resources is 66.7%, which is at most 67 but not
+	// at most 66.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxMatchesPercent *int `json:"maxMatchesPercent"`
+
+	// ResourceDeleting matches resources based on whether they are being
+	// deleted, i.e. have a deletion timestamp. It is evaluated for each
+	// resource alongside Conditions, using the same Type. If Conditions is
+	// empty, the matcher matches on the deletion state alone.
+	// +optional
+	ResourceDeleting *bool `json:"resourceDeleting"`
+
+	// ConnectionDetailsPublished matches resources based on whether they have
+	// published connection details, i.e. Crossplane observed connection
+	// details for them. It is evaluated in the same way as ResourceDeleting.
+	// +optional
+	ConnectionDetailsPublished *bool `json:"connectionDetailsPublished"`
+
+	// NoConditions matches resources based on whether they have no status
+	// conditions at all. It is evaluated in the same way as ResourceDeleting.
+	// +optional
+	NoConditions *bool `json:"noConditions"`
+
+	// ConditionChangedFromDesired matches resources whose condition of this
+	// type has a different status than the same condition of the desired
+	// version of the resource. This approximates detecting a status
+	// transition, e.g. a resource that was Ready but is no longer Ready. It
+	// only works if an earlier function in the pipeline copied the condition
+	// into the desired resource. Resources without the condition in their
+	// desired version never match. It is evaluated in the same way as
+	// ResourceDeleting.
+	// +optional
+	ConditionChangedFromDesired *string `json:"conditionChangedFromDesired"`
+
+	// ConsistentConditions matches resources whose conditions of the supplied
+	// types all have the same status, e.g. to avoid matching a resource that
+	// is Synced but whose Ready condition is still catching up. It is
+	// evaluated in the same way as ResourceDeleting.
+	// +optional
+	ConsistentConditions *ConsistentConditions `json:"consistentConditions"`
+
+	// PresentIn matches resources based on whether they are present in the
+	// observed state, the desired state, or both. When set, Resources also
+	// selects desired resources that are not observed yet. Resources that are
+	// only desired have no status, so their conditions are unknown. It is
+	// evaluated in the same way as ResourceDeleting.
+	// +optional
+	PresentIn *ResourcePresence `json:"presentIn"`
+
+	// SpecNotYetObserved matches resources based on whether their controller
+	// has not yet observed their latest spec, i.e. their metadata.generation
+	// is greater than their status.observedGeneration. Such resources are
+	// still reconciling. Resources without a status.observedGeneration are
+	// treated as observed. It is evaluated in the same way as
+	// ResourceDeleting.
+	// +optional
+	SpecNotYetObserved *bool `json:"specNotYetObserved"`
 }
 
+// +kubebuilder:validation:Enum=ObservedOnly;DesiredOnly;Both
+
+// ResourcePresence is the state a resource is present in.
+type ResourcePresence string
+
+const (
+	// PresentInObservedOnly - The resource is observed but no longer
+	// desired, e.g. because it is being removed.
+	PresentInObservedOnly ResourcePresence = "ObservedOnly"
+
+	// PresentInDesiredOnly - The resource is desired but not yet observed,
+	// e.g. because it is being created.
+	PresentInDesiredOnly ResourcePresence = "DesiredOnly"
+
+	// PresentInBoth - The resource is both observed and desired.
+	PresentInBoth ResourcePresence = "Both"
+)
+
 // ResourceMatcher allows you to select one or more resources.
 type ResourceMatcher struct {
 	// Name used to index the observed resource map. Can also be a regular
 	// expression that will be matched against the observed resource map keys.
 	Name string `json:"name"`
+
+	// MatchMode determines how Name is matched against the observed resource
+	// map keys. Can be one of the following.
+	// Regex - Name is an unanchored regular expression.
+	// Glob - Name is a glob that must match the whole key. * matches any
+	// number of characters and ? matches a single character.
+	// Exact - Name must equal the key.
+	// Optional. Defaults to Regex.
+	// +optional
+	MatchMode *ResourceMatchMode `json:"matchMode"`
+
+	// Anchored requires a Regex Name to match the whole key rather than any
+	// part of it, as if it were wrapped in ^ and $. Optional. Defaults to
+	// false.
+	// +optional
+	Anchored *bool `json:"anchored"`
+
+	// Key determines which key of a composed resource Name is matched
+	// against. Can be one of the following.
+	// Name - The observed resource map key, e.g. my-bucket.
+	// KindAndName - The kind of the resource and the observed resource map
+	// key, separated by a slash, e.g. Bucket/my-bucket.
+	// Optional. Defaults to Name. Extra resources are always matched by name.
+	// +optional
+	Key *ResourceKey `json:"key"`
 }
 
+// +kubebuilder:validation:Enum=Name;KindAndName
+
+// ResourceKey determines which key of a resource is matched.
+type ResourceKey string
+
+const (
+	// ResourceKeyName - The observed resource map key.
+	ResourceKeyName ResourceKey = "Name"
+
+	// ResourceKeyKindAndName - The kind of the resource and the observed
+	// resource map key, separated by a slash.
+	ResourceKeyKindAndName ResourceKey = "KindAndName"
+)
+
+// +kubebuilder:validation:Enum=Regex;Glob;Exact
+
+// ResourceMatchMode determines how a resource name is matched.
+type ResourceMatchMode string
+
+const (
+	// ResourceMatchRegex - The name is an unanchored regular expression.
+	ResourceMatchRegex ResourceMatchMode = "Regex"
+
+	// ResourceMatchGlob - The name is a glob that must match the whole key.
+	ResourceMatchGlob ResourceMatchMode = "Glob"
+
+	// ResourceMatchExact - The name must equal the key.
+	ResourceMatchExact ResourceMatchMode = "Exact"
+)
+
 // ConditionMatcher allows you to specify fields that a condition must match.
 type ConditionMatcher struct {
 	// Type of the condition. Required.
 	Type string `json:"type"`
-	// Status of the condition. If omitted, will be treated as a wildcard.
+	// Status of the condition. If omitted, will be treated as a wildcard. The
+	// same aliases as Condition Status are accepted.
 	Status *metav1.ConditionStatus `json:"status"`
 	// Reason of the condition. If omitted, will be treated as a wildcard.
 	Reason *string `json:"reason"`
+	// ReasonRegex treats Reason as a regular expression rather than an exact
+	// value. The regular expression can have capturing groups, which are made
+	// available to templates in the same way as those captured from Message.
+	// +optional
+	ReasonRegex *bool `json:"reasonRegex"`
 	// Message of the condition. Can be a regular expression. The regular
 	// expression can have capturing groups.
 	// For example: "Something went wrong: (?P<Error>.+)".
 	// The captured groups will be available to the message template when setting
 	// conditions.
 	Message *string `json:"message"`
+	// MessageAnyOf is a list of regular expressions, one of which must match
+	// the message of the condition. Optional. The expressions are tried in
+	// order, and only the capture groups of the first that matches are
+	// available to templates. If Message is also set, both must match.
+	// +optional
+	MessageAnyOf []string `json:"messageAnyOf"`
+	// MessageAnchored requires Message and MessageAnyOf to match the whole
+	// message rather than any part of it, as if they were wrapped in ^ and $.
+	// Optional. Defaults to false.
+	// +optional
+	MessageAnchored *bool `json:"messageAnchored"`
+	// EmptyMessage requires the message of the condition to be empty (true)
+	// or not empty (false). Optional. This is equivalent to a Message of
+	// "^$" (true) or "." (false), but clearer.
+	// +optional
+	EmptyMessage *bool `json:"emptyMessage"`
+	// MessageJSON parses the message of the condition as a JSON object and
+	// captures the values at the supplied field paths, keyed by the name they
+	// are available to templates under, e.g. {"Code": "error.code"}.
+	// Optional. The condition does not match if its message is not a JSON
+	// object, or has no value at any of the field paths. Values that are not
+	// strings are captured as JSON.
+	// +optional
+	MessageJSON map[string]string `json:"messageJSON"`
+	// Exists requires the condition to be present (true) or absent (false) on
+	// the resource. Optional. A missing condition is matched as status Unknown
+	// with an empty reason and message, which cannot otherwise be told apart
+	// from a condition set to Unknown without a reason.
+	// +optional
+	Exists *bool `json:"exists"`
+	// TransitionTime compares the lastTransitionTime of the condition with
+	// that of another condition of the same resource. Optional. Both
+	// conditions must be present. For example, a Ready condition that
+	// transitioned Before the Synced condition has been in its current state
+	// for longer.
+	// +optional
+	TransitionTime *TransitionTimeComparison `json:"transitionTime"`
+	// MaxAge requires the condition to have transitioned at most this long
+	// ago, e.g. to create an event only right after a resource started to
+	// fail. Optional. The condition must be present and have a
+	// lastTransitionTime.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge"`
+	// CompareWithComposite compares a field of the condition with the same
+	// field of a condition of the composite resource. Optional. For example,
+	// a Synced reason that is NotEqual to the one the composite resource
+	// recorded shows that the resource has drifted. A missing condition is
+	// compared as status Unknown with an empty reason and message.
+	// +optional
+	CompareWithComposite *CompositeConditionComparison `json:"compareWithComposite"`
+}
+
+// CompositeConditionComparison compares a field of a condition with the same
+// field of a condition of the composite resource.
+type CompositeConditionComparison struct {
+	// Field of the conditions to compare. Required.
+	Field ConditionField `json:"field"`
+	// Operator used to compare the fields. Optional. Defaults to Equal. Only
+	// Equal and NotEqual are supported.
+	// +optional
+	Operator *ComparisonOperator `json:"operator"`
+	// Type of the condition of the composite resource. Optional. Defaults to
+	// the type of the matched condition.
+	// +optional
+	Type *string `json:"type"`
+}
+
+// +kubebuilder:validation:Enum=Status;Reason;Message
+
+// ConditionField is a field of a condition.
+type ConditionField string
+
+const (
+	// ConditionFieldStatus - The status of the condition.
+	ConditionFieldStatus ConditionField = "Status"
+
+	// ConditionFieldReason - The reason of the condition.
+	ConditionFieldReason ConditionField = "Reason"
+
+	// ConditionFieldMessage - The message of the condition.
+	ConditionFieldMessage ConditionField = "Message"
+)
+
+// TransitionTimeComparison compares the lastTransitionTime of a condition with
+// that of another condition of the same resource.
+type TransitionTimeComparison struct {
+	// Operator used to compare the lastTransitionTimes. Required.
+	Operator TransitionTimeOperator `json:"operator"`
+	// Type of the other condition. Required.
+	Type string `json:"type"`
+}
+
+// +kubebuilder:validation:Enum=Before;After
+
+// TransitionTimeOperator compares two lastTransitionTimes.
+type TransitionTimeOperator string
+
+const (
+	// TransitionedBefore - The condition transitioned strictly before the
+	// other condition.
+	TransitionedBefore TransitionTimeOperator = "Before"
+
+	// TransitionedAfter - The condition transitioned strictly after the
+	// other condition.
+	TransitionedAfter TransitionTimeOperator = "After"
+)
+
+// AggregateHook sets conditions and creates events based on the conditions set
+// by the function, rather than on the conditions of resources.
+type AggregateHook struct {
+	// Name of the hook. Optional. Will be used in logging.
+	// +optional
+	Name *string `json:"name"`
+
+	// WhenSet lists condition types that must have been set, for any
+	// target. Optional. Conditions treated as already set because of
+	// RespectDesiredConditions count as set.
+	// +optional
+	WhenSet []string `json:"whenSet"`
+
+	// WhenNotSet lists condition types that must not have been set, for any
+	// target. Optional.
+	// +optional
+	WhenNotSet []string `json:"whenNotSet"`
+
+	// A list of conditions to set if WhenSet and WhenNotSet matched.
+	SetConditions []SetCondition `json:"setConditions"`
+
+	// A list of events to create if WhenSet and WhenNotSet matched.
+	CreateEvents []CreateEvent `json:"createEvents"`
 }
 
 // StatusConditionHook allows you to set conditions on the composite and claim
 // whenever the managed resource status conditions are in a certain state.
 type StatusConditionHook struct {
+	// Name of the hook. Optional. Will be used in logging.
+	// +optional
+	Name *string `json:"name"`
+
+	// Enabled determines whether the hook is evaluated. Optional. A template
+	// can be used, which must render to true or false. Only the environment is
+	// available to the template, for example {{ eq .Env.name "prod" }}.
+	// Defaults to true.
+	// +optional
+	Enabled *string `json:"enabled"`
+
 	// A list of conditions to match.
 	Matchers []Matcher `json:"matchers"`
 
+	// MatcherRefs are the names of NamedMatchers groups to match in addition
+	// to Matchers. Optional. The referenced matchers are evaluated in order
+	// before Matchers, and like all matchers of a hook they must all match.
+	// +optional
+	MatcherRefs []string `json:"matcherRefs"`
+
+	// GracePeriod suppresses the hook until every resource selected by its
+	// matchers was created at least this long ago. Optional. For example, 5m.
+	// This avoids setting conditions and creating events for resources that
+	// were only just created.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod"`
+
+	// TTL suggests how long Crossplane may cache the function's response
+	// when this hook matches, e.g. 30s to requeue sooner while resources are
+	// still being created. The response uses the shortest TTL suggested by
+	// a matched hook. Optional. If no matched hook suggests a TTL, the
+	// default of 1m is used.
+	// +optional
+	TTL *metav1.Duration `json:"ttl"`
+
+	// EventTTL suggests how long Crossplane may cache the function's response
+	// when this hook creates at least one event, e.g. 10s to requeue quickly
+	// after a transient event. It is combined with TTL in the same way.
+	// Optional. Events that are not created, e.g. because of MaxEvents, don't
+	// apply it.
+	// +optional
+	EventTTL *metav1.Duration `json:"eventTTL"`
+
 	// A list of conditions to set if all MatchConditions matched.
 	SetConditions []SetCondition `json:"setConditions"`
 
@@ -148,14 +1020,24 @@ const (
 
 // Event allows you to specify the fields of an event to create.
 type Event struct {
-	// Type of the event. Optional. Should be either Normal or Warning.
+	// Type of the event. Optional. Should be either Normal or Warning. A
+	// template can be used, in the same way as Message, e.g. to create a
+	// Warning event only when the matched condition's status is False. The
+	// rendered type must be Normal or Warning. If omitted, the type is derived
+	// from the status of the matched conditions, see StatusToSeverity.
 	Type *EventType `json:"type"`
-	// Reason of the event. Optional.
+	// Reason of the event. Optional. A template can be used, in the same way
+	// as Message, e.g. to render "FailedWithCode403". The rendered reason is
+	// only validated if ValidateConditionFormat is enabled.
 	Reason *string `json:"reason"`
 	// Message of the event. Required. A template can be used. The available
 	// template variables come from capturing groups in MatchCondition message
 	// regular expressions.
 	Message string `json:"message"`
+	// MaxMessageLength overrides the maximum length in bytes of the rendered
+	// message. Optional.
+	// +optional
+	MaxMessageLength *int `json:"maxMessageLength"`
 }
 
 // CreateEvent will create an event for the target(s).
@@ -9,6 +9,75 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AggregateHook) DeepCopyInto(out *AggregateHook) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.WhenSet != nil {
+		in, out := &in.WhenSet, &out.WhenSet
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WhenNotSet != nil {
+		in, out := &in.WhenNotSet, &out.WhenNotSet
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SetConditions != nil {
+		in, out := &in.SetConditions, &out.SetConditions
+		*out = make([]SetCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CreateEvents != nil {
+		in, out := &in.CreateEvents, &out.CreateEvents
+		*out = make([]CreateEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AggregateHook.
+func (in *AggregateHook) DeepCopy() *AggregateHook {
+	if in == nil {
+		return nil
+	}
+	out := new(AggregateHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositeConditionComparison) DeepCopyInto(out *CompositeConditionComparison) {
+	*out = *in
+	if in.Operator != nil {
+		in, out := &in.Operator, &out.Operator
+		*out = new(ComparisonOperator)
+		**out = **in
+	}
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositeConditionComparison.
+func (in *CompositeConditionComparison) DeepCopy() *CompositeConditionComparison {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositeConditionComparison)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -17,6 +86,11 @@ func (in *Condition) DeepCopyInto(out *Condition) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.MaxMessageLength != nil {
+		in, out := &in.MaxMessageLength, &out.MaxMessageLength
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
@@ -42,11 +116,58 @@ func (in *ConditionMatcher) DeepCopyInto(out *ConditionMatcher) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ReasonRegex != nil {
+		in, out := &in.ReasonRegex, &out.ReasonRegex
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Message != nil {
 		in, out := &in.Message, &out.Message
 		*out = new(string)
 		**out = **in
 	}
+	if in.MessageAnyOf != nil {
+		in, out := &in.MessageAnyOf, &out.MessageAnyOf
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MessageAnchored != nil {
+		in, out := &in.MessageAnchored, &out.MessageAnchored
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EmptyMessage != nil {
+		in, out := &in.EmptyMessage, &out.EmptyMessage
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MessageJSON != nil {
+		in, out := &in.MessageJSON, &out.MessageJSON
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Exists != nil {
+		in, out := &in.Exists, &out.Exists
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TransitionTime != nil {
+		in, out := &in.TransitionTime, &out.TransitionTime
+		*out = new(TransitionTimeComparison)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CompareWithComposite != nil {
+		in, out := &in.CompareWithComposite, &out.CompareWithComposite
+		*out = new(CompositeConditionComparison)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConditionMatcher.
@@ -59,6 +180,51 @@ func (in *ConditionMatcher) DeepCopy() *ConditionMatcher {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsistentConditions) DeepCopyInto(out *ConsistentConditions) {
+	*out = *in
+	if in.Types != nil {
+		in, out := &in.Types, &out.Types
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(v1.ConditionStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsistentConditions.
+func (in *ConsistentConditions) DeepCopy() *ConsistentConditions {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsistentConditions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CopyCondition) DeepCopyInto(out *CopyCondition) {
+	*out = *in
+	if in.As != nil {
+		in, out := &in.As, &out.As
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CopyCondition.
+func (in *CopyCondition) DeepCopy() *CopyCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(CopyCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CreateEvent) DeepCopyInto(out *CreateEvent) {
 	*out = *in
@@ -93,6 +259,11 @@ func (in *Event) DeepCopyInto(out *Event) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.MaxMessageLength != nil {
+		in, out := &in.MaxMessageLength, &out.MaxMessageLength
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Event.
@@ -118,10 +289,22 @@ func (in *Matcher) DeepCopyInto(out *Matcher) {
 		*out = new(MatchType)
 		**out = **in
 	}
+	if in.StatusCounts != nil {
+		in, out := &in.StatusCounts, &out.StatusCounts
+		*out = new(StatusCountComparison)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceCount != nil {
+		in, out := &in.ResourceCount, &out.ResourceCount
+		*out = new(ResourceCountComparison)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]ResourceMatcher, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -130,11 +313,129 @@ func (in *Matcher) DeepCopyInto(out *Matcher) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConditionRefs != nil {
+		in, out := &in.ConditionRefs, &out.ConditionRefs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.IncludeCompositeAsResource != nil {
 		in, out := &in.IncludeCompositeAsResource, &out.IncludeCompositeAsResource
 		*out = new(bool)
 		**out = **in
 	}
+	if in.CompositeOnly != nil {
+		in, out := &in.CompositeOnly, &out.CompositeOnly
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IncludeExtraResources != nil {
+		in, out := &in.IncludeExtraResources, &out.IncludeExtraResources
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ExtraResources != nil {
+		in, out := &in.ExtraResources, &out.ExtraResources
+		*out = make([]ResourceMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExtraResourcesOnly != nil {
+		in, out := &in.ExtraResourcesOnly, &out.ExtraResourcesOnly
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Absent != nil {
+		in, out := &in.Absent, &out.Absent
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ConditionsPath != nil {
+		in, out := &in.ConditionsPath, &out.ConditionsPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConversionFailed != nil {
+		in, out := &in.ConversionFailed, &out.ConversionFailed
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MatchOwnerReferences != nil {
+		in, out := &in.MatchOwnerReferences, &out.MatchOwnerReferences
+		*out = make([]OwnerReferenceMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatchAnnotations != nil {
+		in, out := &in.MatchAnnotations, &out.MatchAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MinMatches != nil {
+		in, out := &in.MinMatches, &out.MinMatches
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxMatches != nil {
+		in, out := &in.MaxMatches, &out.MaxMatches
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinMatchesPercent != nil {
+		in, out := &in.MinMatchesPercent, &out.MinMatchesPercent
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxMatchesPercent != nil {
+		in, out := &in.MaxMatchesPercent, &out.MaxMatchesPercent
+		*out = new(int)
+		**out = **in
+	}
+	if in.ResourceDeleting != nil {
+		in, out := &in.ResourceDeleting, &out.ResourceDeleting
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ConnectionDetailsPublished != nil {
+		in, out := &in.ConnectionDetailsPublished, &out.ConnectionDetailsPublished
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NoConditions != nil {
+		in, out := &in.NoConditions, &out.NoConditions
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ConditionChangedFromDesired != nil {
+		in, out := &in.ConditionChangedFromDesired, &out.ConditionChangedFromDesired
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConsistentConditions != nil {
+		in, out := &in.ConsistentConditions, &out.ConsistentConditions
+		*out = new(ConsistentConditions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PresentIn != nil {
+		in, out := &in.PresentIn, &out.PresentIn
+		*out = new(ResourcePresence)
+		**out = **in
+	}
+	if in.SpecNotYetObserved != nil {
+		in, out := &in.SpecNotYetObserved, &out.SpecNotYetObserved
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Matcher.
@@ -147,9 +448,151 @@ func (in *Matcher) DeepCopy() *Matcher {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessageAggregation) DeepCopyInto(out *MessageAggregation) {
+	*out = *in
+	if in.Separator != nil {
+		in, out := &in.Separator, &out.Separator
+		*out = new(string)
+		**out = **in
+	}
+	if in.Distinct != nil {
+		in, out := &in.Distinct, &out.Distinct
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessageAggregation.
+func (in *MessageAggregation) DeepCopy() *MessageAggregation {
+	if in == nil {
+		return nil
+	}
+	out := new(MessageAggregation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnerReferenceMatcher) DeepCopyInto(out *OwnerReferenceMatcher) {
+	*out = *in
+	if in.APIVersion != nil {
+		in, out := &in.APIVersion, &out.APIVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.Controller != nil {
+		in, out := &in.Controller, &out.Controller
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnerReferenceMatcher.
+func (in *OwnerReferenceMatcher) DeepCopy() *OwnerReferenceMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnerReferenceMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessRollup) DeepCopyInto(out *ReadinessRollup) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConditionTypes != nil {
+		in, out := &in.ConditionTypes, &out.ConditionTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(string)
+		**out = **in
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(Target)
+		**out = **in
+	}
+	if in.UnknownWhileCreating != nil {
+		in, out := &in.UnknownWhileCreating, &out.UnknownWhileCreating
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CreatingReasons != nil {
+		in, out := &in.CreatingReasons, &out.CreatingReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessRollup.
+func (in *ReadinessRollup) DeepCopy() *ReadinessRollup {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessRollup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCountComparison) DeepCopyInto(out *ResourceCountComparison) {
+	*out = *in
+	if in.Operator != nil {
+		in, out := &in.Operator, &out.Operator
+		*out = new(ComparisonOperator)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCountComparison.
+func (in *ResourceCountComparison) DeepCopy() *ResourceCountComparison {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCountComparison)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceMatcher) DeepCopyInto(out *ResourceMatcher) {
 	*out = *in
+	if in.MatchMode != nil {
+		in, out := &in.MatchMode, &out.MatchMode
+		*out = new(ResourceMatchMode)
+		**out = **in
+	}
+	if in.Anchored != nil {
+		in, out := &in.Anchored, &out.Anchored
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(ResourceKey)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceMatcher.
@@ -162,6 +605,40 @@ func (in *ResourceMatcher) DeepCopy() *ResourceMatcher {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceSelector) DeepCopyInto(out *ResourceSelector) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatchAnnotations != nil {
+		in, out := &in.MatchAnnotations, &out.MatchAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceSelector.
+func (in *ResourceSelector) DeepCopy() *ResourceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SetCondition) DeepCopyInto(out *SetCondition) {
 	*out = *in
@@ -176,6 +653,28 @@ func (in *SetCondition) DeepCopyInto(out *SetCondition) {
 		**out = **in
 	}
 	in.Condition.DeepCopyInto(&out.Condition)
+	if in.CopyCondition != nil {
+		in, out := &in.CopyCondition, &out.CopyCondition
+		*out = new(CopyCondition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AggregateMessages != nil {
+		in, out := &in.AggregateMessages, &out.AggregateMessages
+		*out = new(MessageAggregation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreserveTransitionTime != nil {
+		in, out := &in.PreserveTransitionTime, &out.PreserveTransitionTime
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Preconditions != nil {
+		in, out := &in.Preconditions, &out.Preconditions
+		*out = make([]ConditionMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetCondition.
@@ -191,6 +690,16 @@ func (in *SetCondition) DeepCopy() *SetCondition {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatusConditionHook) DeepCopyInto(out *StatusConditionHook) {
 	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(string)
+		**out = **in
+	}
 	if in.Matchers != nil {
 		in, out := &in.Matchers, &out.Matchers
 		*out = make([]Matcher, len(*in))
@@ -198,6 +707,26 @@ func (in *StatusConditionHook) DeepCopyInto(out *StatusConditionHook) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MatcherRefs != nil {
+		in, out := &in.MatcherRefs, &out.MatcherRefs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.EventTTL != nil {
+		in, out := &in.EventTTL, &out.EventTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.SetConditions != nil {
 		in, out := &in.SetConditions, &out.SetConditions
 		*out = make([]SetCondition, len(*in))
@@ -224,6 +753,26 @@ func (in *StatusConditionHook) DeepCopy() *StatusConditionHook {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusCountComparison) DeepCopyInto(out *StatusCountComparison) {
+	*out = *in
+	if in.Operator != nil {
+		in, out := &in.Operator, &out.Operator
+		*out = new(ComparisonOperator)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusCountComparison.
+func (in *StatusCountComparison) DeepCopy() *StatusCountComparison {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusCountComparison)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatusTransformation) DeepCopyInto(out *StatusTransformation) {
 	*out = *in
@@ -236,6 +785,166 @@ func (in *StatusTransformation) DeepCopyInto(out *StatusTransformation) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.NamedMatchers != nil {
+		in, out := &in.NamedMatchers, &out.NamedMatchers
+		*out = make(map[string][]Matcher, len(*in))
+		for key, val := range *in {
+			var outVal []Matcher
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]Matcher, len(*in))
+				for i := range *in {
+					(*in)[i].DeepCopyInto(&(*out)[i])
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NamedConditions != nil {
+		in, out := &in.NamedConditions, &out.NamedConditions
+		*out = make(map[string][]ConditionMatcher, len(*in))
+		for key, val := range *in {
+			var outVal []ConditionMatcher
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]ConditionMatcher, len(*in))
+				for i := range *in {
+					(*in)[i].DeepCopyInto(&(*out)[i])
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.ReadinessRollup != nil {
+		in, out := &in.ReadinessRollup, &out.ReadinessRollup
+		*out = new(ReadinessRollup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WhenAllHooksEvaluated != nil {
+		in, out := &in.WhenAllHooksEvaluated, &out.WhenAllHooksEvaluated
+		*out = make([]AggregateHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceSelector != nil {
+		in, out := &in.ResourceSelector, &out.ResourceSelector
+		*out = new(ResourceSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(LogLevel)
+		**out = **in
+	}
+	if in.EnvironmentContextKey != nil {
+		in, out := &in.EnvironmentContextKey, &out.EnvironmentContextKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.HooksContextKey != nil {
+		in, out := &in.HooksContextKey, &out.HooksContextKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.OnGroupConflict != nil {
+		in, out := &in.OnGroupConflict, &out.OnGroupConflict
+		*out = new(GroupConflictPolicy)
+		**out = **in
+	}
+	if in.EmitErrorEvents != nil {
+		in, out := &in.EmitErrorEvents, &out.EmitErrorEvents
+		*out = new(bool)
+		**out = **in
+	}
+	if in.StrictMatching != nil {
+		in, out := &in.StrictMatching, &out.StrictMatching
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EmitSuccessCondition != nil {
+		in, out := &in.EmitSuccessCondition, &out.EmitSuccessCondition
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReportNoMatch != nil {
+		in, out := &in.ReportNoMatch, &out.ReportNoMatch
+		*out = new(bool)
+		**out = **in
+	}
+	if in.WarnOnEmpty != nil {
+		in, out := &in.WarnOnEmpty, &out.WarnOnEmpty
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EmitSuccessEvent != nil {
+		in, out := &in.EmitSuccessEvent, &out.EmitSuccessEvent
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SuccessEventMessage != nil {
+		in, out := &in.SuccessEventMessage, &out.SuccessEventMessage
+		*out = new(string)
+		**out = **in
+	}
+	if in.SuccessConditionTarget != nil {
+		in, out := &in.SuccessConditionTarget, &out.SuccessConditionTarget
+		*out = new(Target)
+		**out = **in
+	}
+	if in.MaxMessageLength != nil {
+		in, out := &in.MaxMessageLength, &out.MaxMessageLength
+		*out = new(int)
+		**out = **in
+	}
+	if in.MessagePrefix != nil {
+		in, out := &in.MessagePrefix, &out.MessagePrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.MessageSuffix != nil {
+		in, out := &in.MessageSuffix, &out.MessageSuffix
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxEvents != nil {
+		in, out := &in.MaxEvents, &out.MaxEvents
+		*out = new(int)
+		**out = **in
+	}
+	if in.StatusToSeverity != nil {
+		in, out := &in.StatusToSeverity, &out.StatusToSeverity
+		*out = make(map[string]EventType, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MaxMatchedMessageLength != nil {
+		in, out := &in.MaxMatchedMessageLength, &out.MaxMatchedMessageLength
+		*out = new(int)
+		**out = **in
+	}
+	if in.RespectDesiredConditions != nil {
+		in, out := &in.RespectDesiredConditions, &out.RespectDesiredConditions
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SortConditions != nil {
+		in, out := &in.SortConditions, &out.SortConditions
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ValidateConditionFormat != nil {
+		in, out := &in.ValidateConditionFormat, &out.ValidateConditionFormat
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusTransformation.
@@ -255,3 +964,18 @@ func (in *StatusTransformation) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitionTimeComparison) DeepCopyInto(out *TransitionTimeComparison) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitionTimeComparison.
+func (in *TransitionTimeComparison) DeepCopy() *TransitionTimeComparison {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitionTimeComparison)
+	in.DeepCopyInto(out)
+	return out
+}
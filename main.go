@@ -2,34 +2,144 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
 	"github.com/alecthomas/kong"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/yaml"
 
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/function-sdk-go"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
 )
 
 // CLI of this Function.
 type CLI struct {
+	Serve  ServeCmd  `cmd:"" default:"withargs" help:"Serve the Function. This is the default command."`
+	Render RenderCmd `cmd:"" help:"Run the Function once against local files and print the response."`
+}
+
+// ServeCmd serves this Function.
+type ServeCmd struct {
 	Debug bool `short:"d" help:"Emit debug logs in addition to info logs."`
 
 	Network     string `help:"Network on which to listen for gRPC connections." default:"tcp"`
 	Address     string `help:"Address at which to listen for gRPC connections." default:":9443"`
 	TLSCertsDir string `help:"Directory containing server certs (tls.key, tls.crt) and the CA used to verify client certificates (ca.crt)" env:"TLS_SERVER_CERTS_DIR"`
 	Insecure    bool   `help:"Run without mTLS credentials. If you supply this flag --tls-server-certs-dir will be ignored."`
+
+	HealthProbeAddress string `help:"Address at which to serve the HTTP health probe at /healthz. Disabled if empty."`
+
+	AllowUnknownInputFields bool `help:"Ignore unknown fields in the Function input with a warning, rather than failing."`
+
+	TemplateEnvVars []string `help:"Environment variables of the Function that are available to templates as PodEnv. Other environment variables are never exposed." env:"TEMPLATE_ENV_VARS"`
 }
 
 // Run this Function.
-func (c *CLI) Run() error {
+func (c *ServeCmd) Run() error {
 	log, err := function.NewLogger(c.Debug)
 	if err != nil {
 		return err
 	}
 
-	return function.Serve(&Function{log: log},
+	f := &Function{log: log, clock: clock.RealClock{}, debug: c.Debug, allowUnknownInputFields: c.AllowUnknownInputFields, podEnv: podEnv(c.TemplateEnvVars, os.LookupEnv)}
+
+	if c.HealthProbeAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", f)
+		srv := &http.Server{Addr: c.HealthProbeAddress, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil {
+				log.Info("cannot serve health probe", "error", err)
+			}
+		}()
+	}
+
+	return function.Serve(f,
 		function.Listen(c.Network, c.Address),
 		function.MTLSCertificates(c.TLSCertsDir),
 		function.Insecure(c.Insecure))
 }
 
+// RenderCmd runs this Function once against local files.
+type RenderCmd struct {
+	Debug bool `short:"d" help:"Emit debug logs in addition to info logs."`
+
+	Input    string `arg:"" type:"existingfile" help:"A YAML file containing the StatusTransformation input."`
+	Observed string `arg:"" type:"existingfile" help:"A YAML file containing the observed state, i.e. the composite resource and composed resources, in the same form as a RunFunctionRequest's observed field."`
+
+	AllowUnknownInputFields bool `help:"Ignore unknown fields in the Function input with a warning, rather than failing."`
+
+	TemplateEnvVars []string `help:"Environment variables that are available to templates as PodEnv. Other environment variables are never exposed." env:"TEMPLATE_ENV_VARS"`
+}
+
+// Run this Function once and print the response as YAML.
+func (c *RenderCmd) Run() error {
+	log, err := function.NewLogger(c.Debug)
+	if err != nil {
+		return err
+	}
+	input, err := os.ReadFile(c.Input)
+	if err != nil {
+		return errors.Wrap(err, "cannot read input")
+	}
+	observed, err := os.ReadFile(c.Observed)
+	if err != nil {
+		return errors.Wrap(err, "cannot read observed state")
+	}
+
+	f := &Function{log: log, clock: clock.RealClock{}, debug: c.Debug, allowUnknownInputFields: c.AllowUnknownInputFields, podEnv: podEnv(c.TemplateEnvVars, os.LookupEnv)}
+	return render(context.Background(), f, input, observed, os.Stdout)
+}
+
+// render runs the supplied Function against the supplied YAML input and
+// observed state, and writes the response to w as YAML.
+func render(ctx context.Context, f *Function, input, observed []byte, w io.Writer) error {
+	req := &fnv1.RunFunctionRequest{
+		Input:    &structpb.Struct{},
+		Observed: &fnv1.State{},
+	}
+
+	j, err := yaml.YAMLToJSON(input)
+	if err != nil {
+		return errors.Wrap(err, "cannot convert input to JSON")
+	}
+	if err := protojson.Unmarshal(j, req.GetInput()); err != nil {
+		return errors.Wrap(err, "cannot parse input")
+	}
+
+	j, err = yaml.YAMLToJSON(observed)
+	if err != nil {
+		return errors.Wrap(err, "cannot convert observed state to JSON")
+	}
+	if err := protojson.Unmarshal(j, req.GetObserved()); err != nil {
+		return errors.Wrap(err, "cannot parse observed state")
+	}
+
+	rsp, err := f.RunFunction(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "cannot run function")
+	}
+
+	j, err = protojson.Marshal(rsp)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal response to JSON")
+	}
+	y, err := yaml.JSONToYAML(j)
+	if err != nil {
+		return errors.Wrap(err, "cannot convert response to YAML")
+	}
+	_, err = fmt.Fprint(w, string(y))
+	return errors.Wrap(err, "cannot write response")
+}
+
 func main() {
 	ctx := kong.Parse(&CLI{}, kong.Description("A Crossplane Composition Function."))
 	ctx.FatalIfErrorf(ctx.Run())
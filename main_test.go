@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+var update = flag.Bool("update", false, "Update the golden files.")
+
+func TestRender(t *testing.T) {
+	dir := filepath.Join("testdata", "render")
+	input, err := os.ReadFile(filepath.Join(dir, "input.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	observed, err := os.ReadFile(filepath.Join(dir, "observed.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := &bytes.Buffer{}
+	f := &Function{log: logging.NewNopLogger()}
+	if err := render(context.Background(), f, input, observed, got); err != nil {
+		t.Fatalf("render(...): unexpected error: %v", err)
+	}
+
+	golden := filepath.Join(dir, "response.golden.yaml")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), got.String()); diff != "" {
+		t.Errorf("render(...): -want, +got:\n%s\nRun go test -run TestRender -update to update the golden file.", diff)
+	}
+}